@@ -0,0 +1,188 @@
+package goforarun
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+// AdminServerName is the name of the framework server exposing the diagnostics.
+const AdminServerName = "admin"
+
+// AdminConfig is the configuration of the admin server, serving pprof, expvar, a goroutine dump, the build info and
+// the redacted config on its own listener.
+type AdminConfig struct {
+	// Enabled starts the admin server
+	Enabled bool `yaml:"enabled"`
+	// Host is the interface the server listens on (default 127.0.0.1)
+	Host string `yaml:"host"`
+	// Port is the port the server listens on (default 6060)
+	Port string `yaml:"port"`
+	// AllowedNetworks are the CIDRs of the clients allowed to connect (default the loopback ones)
+	AllowedNetworks []string `yaml:"allowed_networks"`
+	// Token is the bearer token required by every request, none when empty
+	Token string `yaml:"token"`
+}
+
+// adminServer serves the diagnostics, managed by the framework alongside the app servers.
+type adminServer struct {
+	info       *InfoServer
+	handler    http.Handler
+	httpServer *http.Server
+	logger     *slog.Logger
+}
+
+func newAdminServer(c AdminConfig, cfg Config, t *observability.Telemetry) (*adminServer, error) {
+	info := &InfoServer{Net: "tcp", Host: c.Host, Port: c.Port, Name: AdminServerName}
+	if info.Host == "" {
+		info.Host = "127.0.0.1"
+	}
+	if info.Port == "" {
+		info.Port = "6060"
+	}
+
+	networks := c.AllowedNetworks
+	if len(networks) == 0 {
+		networks = []string{"127.0.0.0/8", "::1/128"}
+	}
+	allowed := make([]*net.IPNet, 0, len(networks))
+	for _, n := range networks {
+		_, ipNet, err := net.ParseCIDR(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid admin allowed network %s: %w", n, err)
+		}
+		allowed = append(allowed, ipNet)
+	}
+
+	policy, err := cfg.Framework().LoggingConfig.Redaction.policy()
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	mux.HandleFunc("GET /debug/goroutines", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = rpprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+	mux.HandleFunc("GET /buildinfo", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"build":      cfg.Framework().BuildInfo,
+			"go_version": runtime.Version(),
+		})
+	})
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		tree, err := configTree(cfg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, policy.RedactTree(tree))
+	})
+
+	handler := restrictAdmin(mux, allowed, c.Token)
+
+	return &adminServer{
+		info:    info,
+		handler: handler,
+		// built here rather than in Run so that Shutdown, called from another goroutine, does not race with it
+		httpServer: &http.Server{
+			Addr:    net.JoinHostPort(info.Host, info.Port),
+			Handler: handler,
+		},
+		logger: t.Logger(AppLoggerName, slog.String("server", AdminServerName)),
+	}, nil
+}
+
+func (s *adminServer) Run(context.Context) error {
+	s.logger.With("connection", s.info).Info("listening server")
+
+	err := s.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *adminServer) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *adminServer) Info() *InfoServer {
+	return s.info
+}
+
+// restrictAdmin rejects the clients outside the allowed networks and, when a token is set, the requests without it.
+func restrictAdmin(next http.Handler, allowed []*net.IPNet, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		ip := net.ParseIP(host)
+		if ip == nil || !containsIP(allowed, ip) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// configTree turns the config into maps and slices keyed by the yaml names.
+func configTree(cfg Config) (any, error) {
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode config: %w", err)
+	}
+
+	var tree any
+	if err = yaml.Unmarshal(b, &tree); err != nil {
+		return nil, fmt.Errorf("could not decode config: %w", err)
+	}
+	return tree, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,136 @@
+package goforarun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun/logger"
+	"github.com/davfer/goforarun/observability"
+)
+
+type adminTestConfig struct {
+	FrameworkConfig *BaseAppConfig `yaml:"framework"`
+	Database        struct {
+		User string `yaml:"user"`
+		SSN  string `yaml:"ssn"`
+	} `yaml:"database"`
+}
+
+func (c *adminTestConfig) Framework() *BaseAppConfig {
+	return c.FrameworkConfig
+}
+
+func newTestAdminServer(t *testing.T, c AdminConfig) *adminServer {
+	cfg := &adminTestConfig{FrameworkConfig: &BaseAppConfig{ServiceName: "test", Admin: c}}
+	cfg.FrameworkConfig.LoggingConfig.Redaction.Keys = []string{"ssn"}
+	cfg.Database.User = "app"
+	cfg.Database.SSN = "123-45-6789"
+
+	s, err := newAdminServer(c, cfg, observability.Global())
+	require.NoError(t, err)
+	return s
+}
+
+func adminRequest(s *adminServer, remoteAddr, token, path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.RemoteAddr = remoteAddr
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestAdminServerAllowedNetworks(t *testing.T) {
+	s := newTestAdminServer(t, AdminConfig{AllowedNetworks: []string{"10.0.0.0/8"}})
+
+	assert.Equal(t, http.StatusForbidden, adminRequest(s, "192.168.1.1:1234", "", "/buildinfo").Code)
+	assert.Equal(t, http.StatusForbidden, adminRequest(s, "127.0.0.1:1234", "", "/buildinfo").Code)
+	assert.Equal(t, http.StatusOK, adminRequest(s, "10.1.2.3:1234", "", "/buildinfo").Code)
+
+	s = newTestAdminServer(t, AdminConfig{})
+	assert.Equal(t, http.StatusForbidden, adminRequest(s, "10.1.2.3:1234", "", "/buildinfo").Code)
+	assert.Equal(t, http.StatusOK, adminRequest(s, "[::1]:1234", "", "/buildinfo").Code)
+}
+
+func TestAdminServerToken(t *testing.T) {
+	s := newTestAdminServer(t, AdminConfig{Token: "secret"})
+
+	rec := adminRequest(s, "127.0.0.1:1234", "", "/buildinfo")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(s, "127.0.0.1:1234", "wrong", "/buildinfo").Code)
+	assert.Equal(t, http.StatusOK, adminRequest(s, "127.0.0.1:1234", "secret", "/buildinfo").Code)
+}
+
+func TestAdminServerConfigRedacted(t *testing.T) {
+	s := newTestAdminServer(t, AdminConfig{Token: "secret"})
+
+	rec := adminRequest(s, "127.0.0.1:1234", "secret", "/config")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var tree map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tree))
+	framework := tree["framework"].(map[string]any)
+	assert.Equal(t, logger.RedactedValue, framework["admin"].(map[string]any)["token"])
+	assert.Equal(t, "test", framework["service_name"])
+	database := tree["database"].(map[string]any)
+	assert.Equal(t, logger.RedactedValue, database["ssn"])
+	assert.Equal(t, "app", database["user"])
+	assert.NotContains(t, rec.Body.String(), "123-45-6789")
+}
+
+func TestAdminServerDiagnostics(t *testing.T) {
+	s := newTestAdminServer(t, AdminConfig{})
+
+	assert.Equal(t, http.StatusOK, adminRequest(s, "127.0.0.1:1234", "", "/debug/pprof/").Code)
+	assert.Equal(t, http.StatusOK, adminRequest(s, "127.0.0.1:1234", "", "/debug/vars").Code)
+	assert.Contains(t, adminRequest(s, "127.0.0.1:1234", "", "/debug/goroutines").Body.String(), "goroutine")
+}
+
+func TestAdminServerConfigRedactionPolicy(t *testing.T) {
+	cfg := &adminTestConfig{FrameworkConfig: &BaseAppConfig{ServiceName: "test", Admin: AdminConfig{Token: "secret"}}}
+	cfg.FrameworkConfig.LoggingConfig.Redaction = LogRedactionConfig{
+		SkipDefaults: true,
+		Patterns:     []string{`\d{3}-\d{2}-\d{4}`},
+		Replacement:  "***",
+	}
+	cfg.Database.SSN = "123-45-6789"
+	s, err := newAdminServer(cfg.FrameworkConfig.Admin, cfg, observability.Global())
+	require.NoError(t, err)
+
+	rec := adminRequest(s, "127.0.0.1:1234", "secret", "/config")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var tree map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tree))
+	assert.Equal(t, "***", tree["database"].(map[string]any)["ssn"])
+	assert.Equal(t, "secret", tree["framework"].(map[string]any)["admin"].(map[string]any)["token"])
+}
+
+func TestAdminServerInvalidRedactionPattern(t *testing.T) {
+	cfg := &adminTestConfig{FrameworkConfig: &BaseAppConfig{}}
+	cfg.FrameworkConfig.LoggingConfig.Redaction.Patterns = []string{"("}
+
+	_, err := newAdminServer(AdminConfig{}, cfg, observability.Global())
+	assert.ErrorContains(t, err, "invalid redaction pattern")
+}
+
+func TestAdminServerShutdown(t *testing.T) {
+	s := newTestAdminServer(t, AdminConfig{Port: "0"})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Run(context.Background()) }()
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, s.Shutdown(context.Background()))
+	assert.NoError(t, <-errCh)
+}
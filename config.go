@@ -12,8 +12,18 @@ import (
 type BaseAppConfig struct {
 	// ServiceName is the identifier of the service
 	ServiceName string `yaml:"service_name"`
+	// Environment is the deployment environment of the service, e.g. production or staging
+	Environment string `yaml:"environment"`
+	// Resource are custom attributes describing the service in every signal
+	Resource map[string]string `yaml:"resource"`
 	// LoggingConfig is the configuration of the logs
 	LoggingConfig LoggingConfig `yaml:"logs"`
+	// ObservabilityConfig is the configuration of the telemetry exporters
+	ObservabilityConfig ObservabilityConfig `yaml:"observability"`
+	// Admin is the configuration of the diagnostics server
+	Admin AdminConfig `yaml:"admin"`
+	// Profiling is the configuration of the continuous profiling
+	Profiling ProfilingConfig `yaml:"profiling"`
 	// BuildInfo is the information of the build. Useful to identify running process for observability.
 	BuildInfo *BuildInfo
 }
@@ -2,10 +2,14 @@ package main
 
 import (
 	app "github.com/davfer/goforarun"
+	gofarhttp "github.com/davfer/goforarun/http"
 )
 
 type HttpServiceConfig struct {
-	FrameworkConfig *app.BaseAppConfig `yaml:"framework"`
+	FrameworkConfig *app.BaseAppConfig         `yaml:"framework"`
+	Server          app.InfoServer             `yaml:"server"`
+	HTTP            gofarhttp.Config           `yaml:"http"`
+	Middleware      gofarhttp.MiddlewareConfig `yaml:"middleware"`
 }
 
 func (c *HttpServiceConfig) Framework() *app.BaseAppConfig {
@@ -8,25 +8,35 @@ import (
 	app "github.com/davfer/goforarun"
 	gofarhttp "github.com/davfer/goforarun/http"
 	"github.com/davfer/goforarun/logger"
+	"github.com/davfer/goforarun/observability"
 )
 
 type HttpService struct {
-	cfg    *HttpServiceConfig
-	logger *slog.Logger
+	cfg       *HttpServiceConfig
+	logger    *slog.Logger
+	telemetry *observability.Telemetry
+}
+
+func (e *HttpService) SetTelemetry(t *observability.Telemetry) {
+	e.telemetry = t
 }
 
 func (e *HttpService) Init(cfg *HttpServiceConfig) ([]app.RunnableServer, error) {
 	e.cfg = cfg
 	e.logger = logger.Get("http-server")
 
-	server := gofarhttp.NewHttpBaseServer(&app.InfoServer{
-		Net:  "tcp",
-		Host: "",
-		Port: "8090",
-		Name: "server",
-	}, func(w http.ResponseWriter, r *http.Request) {
+	middlewares, err := cfg.Middleware.Middlewares(e.telemetry)
+	if err != nil {
+		return nil, err
+	}
+
+	server := gofarhttp.NewHttpBaseServer(&cfg.Server, nil,
+		gofarhttp.WithConfig(cfg.HTTP),
+		gofarhttp.WithMiddleware(middlewares...),
+	)
+	server.HandleFunc("GET /hello/{name}", func(w http.ResponseWriter, r *http.Request) {
 		e.logger.Info("request received", slog.String("method", r.Method))
-		w.Write([]byte("Hello, world!\n"))
+		w.Write([]byte("Hello, " + r.PathValue("name") + "!\n"))
 	})
 
 	return []app.RunnableServer{server}, nil
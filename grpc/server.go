@@ -6,10 +6,12 @@ import (
 	"net"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 
 	"github.com/davfer/goforarun"
 	"github.com/davfer/goforarun/logger"
+	"github.com/davfer/goforarun/observability"
 )
 
 type BaseServer struct {
@@ -30,14 +32,21 @@ func NewGrpcBaseServer(info *goforarun.InfoServer, registrars []ServiceRegisterF
 }
 
 func (cs *BaseServer) Run(ctx context.Context) error {
+	telemetry := observability.FromContext(ctx)
+	cs.logger = telemetry.Logger("grpc-server", slog.String("name", cs.info.Name))
 	cs.grpcServer = grpc.NewServer(
+		// traces and metrics of the calls, extracting the context with the propagator of the service telemetry
+		grpc.StatsHandler(otelgrpc.NewServerHandler(
+			otelgrpc.WithTracerProvider(telemetry.TracerProvider),
+			otelgrpc.WithMeterProvider(telemetry.MeterProvider),
+			otelgrpc.WithPropagators(telemetry.Propagator),
+		)),
 	// TODO v2 https://github.com/grpc-ecosystem/go-grpc-middleware
 	//grpc.UnaryInterceptor(
 	//	grpcmiddleware(
 	//		//grpclogrus.UnaryServerInterceptor(cs.logger.With("type", "interceptor")),
 	//		grpcvalidator.UnaryServerInterceptor(),
 	//		grpcrecovery.UnaryServerInterceptor(),
-	//		//otelgrpc.UnaryServerInterceptor(),
 	//	),
 	//),
 	//grpc.StreamInterceptor(
@@ -45,7 +54,6 @@ func (cs *BaseServer) Run(ctx context.Context) error {
 	//		//grpclogrus.StreamServerInterceptor(cs.logger.WithField("type", "interceptor")),
 	//		grpcvalidator.StreamServerInterceptor(),
 	//		grpcrecovery.StreamServerInterceptor(),
-	//		//otelgrpc.StreamServerInterceptor(),
 	//	),
 	//),
 	)
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRequestIDHeader is the header carrying the request ID when none is configured.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// DefaultAccessLogChannel is the channel of the access logs when none is configured.
+const DefaultAccessLogChannel = "http-access"
+
+type requestIDCtxKey struct{}
+
+// Recovery answers 500 to the requests whose handler panics and logs the panic with its stack on l. The
+// http.ErrAbortHandler panics are left to the server, which aborts the response silently.
+func Recovery(l *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				l.ErrorContext(r.Context(), "panic serving request",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Any("panic", rec),
+					slog.String("stack", string(debug.Stack())),
+				)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestID propagates the request ID of the header, DefaultRequestIDHeader when empty, generating one when the
+// request has none. The ID is set on the response header and read with RequestIDFromContext.
+func RequestID(header string) Middleware {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if id == "" {
+				id = uuid.NewString()
+				r.Header.Set(header, id)
+			}
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDCtxKey{}, id)))
+		})
+	}
+}
+
+// RequestIDFromContext returns the request ID set by the RequestID middleware, empty when there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// AccessLog logs a record per request on l once it is served.
+func AccessLog(l *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", Route(r.Context())),
+				slog.Int("status", rw.status),
+				slog.Int64("bytes", rw.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("client_ip", ClientIP(r)),
+			}
+			if id := RequestIDFromContext(r.Context()); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			l.LogAttrs(r.Context(), slog.LevelInfo, "request served", attrs...)
+		})
+	}
+}
+
+// Timeout answers 503 to the requests not served within d, and cancels their context. The handlers cannot flush or
+// hijack the response, as it is buffered until they return.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, http.StatusText(http.StatusServiceUnavailable))
+	}
+}
+
+// MaxBodySize answers 413 to the requests announcing a body larger than n bytes, and fails the reads of the bodies
+// going past it.
+func MaxBodySize(n int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RealIP sets the remote address of the requests coming from a trusted proxy to the client IP of their headers. The
+// X-Forwarded-For entries are read from the right, the client being the first one that is not a trusted proxy, and
+// X-Real-IP is used when it is missing. The proxies are IPs or CIDRs.
+func RealIP(trustedProxies []string) (Middleware, error) {
+	trusted := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, p := range trustedProxies {
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(p)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %s: %w", p, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+	isTrusted := func(addr netip.Addr) bool {
+		addr = addr.Unmap()
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remote, err := netip.ParseAddr(ClientIP(r))
+			if err != nil || !isTrusted(remote) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if client, ok := forwardedFor(r.Header.Values("X-Forwarded-For"), isTrusted); ok {
+				r.RemoteAddr = client.String()
+			} else if client, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+				r.RemoteAddr = client.String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// forwardedFor returns the rightmost address of the X-Forwarded-For headers that is not a trusted proxy.
+func forwardedFor(headers []string, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var hops []string
+	for _, h := range headers {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		if !isTrusted(addr) {
+			return addr, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// ClientIP returns the IP of the client of the request, the one of the headers of the trusted proxies when RealIP is
+// used.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Defaults of the Config fields left empty, bounding the time and memory a client can hold.
+const (
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 2 * time.Minute
+	DefaultMaxHeaderBytes    = http.DefaultMaxHeaderBytes
+)
+
+// Config is the configuration of the http.Server of a BaseServer. The durations left empty get their default, a
+// negative one disables the timeout, e.g. the write timeout of a server streaming its responses.
+type Config struct {
+	// ReadTimeout is the maximum duration to read a request, body included (default 30s)
+	ReadTimeout time.Duration `yaml:"read_timeout"`
+	// ReadHeaderTimeout is the maximum duration to read the headers of a request (default 10s)
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	// WriteTimeout is the maximum duration to write a response, from the end of the headers read (default 30s)
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// IdleTimeout is the maximum duration a keep-alive connection waits for the next request (default 2m)
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// MaxHeaderBytes is the maximum size of the request headers (default 1MB)
+	MaxHeaderBytes int `yaml:"max_header_bytes"`
+	// KeepAlive keeps the connections open between requests (default true)
+	KeepAlive *bool `yaml:"keep_alive"`
+	// LogConnState logs the state changes of the connections at debug level
+	LogConnState bool `yaml:"log_conn_state"`
+	// TLS serves HTTPS, with the certificates reloaded when they change
+	TLS TLSConfig `yaml:"tls"`
+}
+
+// WithConfig sets the timeouts, the limits and the TLS of the server.
+func WithConfig(c Config) Customizer {
+	return func(cs *BaseServer) {
+		cs.config = c
+	}
+}
+
+// apply sets the timeouts and limits of the config, or their defaults, on the server.
+func (c Config) apply(s *http.Server) {
+	s.ReadTimeout = timeout(c.ReadTimeout, DefaultReadTimeout)
+	s.ReadHeaderTimeout = timeout(c.ReadHeaderTimeout, DefaultReadHeaderTimeout)
+	s.WriteTimeout = timeout(c.WriteTimeout, DefaultWriteTimeout)
+	s.IdleTimeout = timeout(c.IdleTimeout, DefaultIdleTimeout)
+	s.MaxHeaderBytes = c.MaxHeaderBytes
+	if s.MaxHeaderBytes <= 0 {
+		s.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	s.SetKeepAlivesEnabled(c.KeepAlive == nil || *c.KeepAlive)
+}
+
+// timeout is d, def when it is empty and none when it is negative.
+func timeout(d, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		return 0
+	default:
+		return d
+	}
+}
+
+// logConnState logs the state changes of the connections.
+func (cs *BaseServer) logConnState(conn net.Conn, state http.ConnState) {
+	cs.logger.Debug("connection state changed",
+		"remote_addr", conn.RemoteAddr().String(),
+		"state", state.String(),
+	)
+}
@@ -0,0 +1,84 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/davfer/goforarun"
+	gofarhttp "github.com/davfer/goforarun/http"
+)
+
+func freePort(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func TestConfigReadHeaderTimeout(t *testing.T) {
+	var cfg struct {
+		Server goforarun.InfoServer `yaml:"server"`
+		HTTP   gofarhttp.Config     `yaml:"http"`
+	}
+	err := yaml.Unmarshal([]byte(`
+server:
+  host: 127.0.0.1
+  port: "`+freePort(t)+`"
+  name: test
+http:
+  read_header_timeout: 50ms
+`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 50*time.Millisecond, cfg.HTTP.ReadHeaderTimeout)
+
+	server := gofarhttp.NewHttpBaseServer(&cfg.Server, nil, gofarhttp.WithConfig(cfg.HTTP))
+	go func() { _ = server.Run(context.Background()) }()
+	defer server.Shutdown(context.Background())
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		conn, err = net.Dial("tcp", net.JoinHostPort(cfg.Server.Host, cfg.Server.Port))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer conn.Close()
+
+	// a client never ending its headers is disconnected
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, err = io.ReadAll(conn)
+	assert.NoError(t, err, "the server closes the connection before the client deadline")
+}
+
+func TestBaseServerShutdownRunReturnsNil(t *testing.T) {
+	info := &goforarun.InfoServer{Name: "test", Host: "127.0.0.1", Port: freePort(t)}
+	server := gofarhttp.NewHttpBaseServer(info, nil)
+	done := make(chan error, 1)
+	go func() { done <- server.Run(context.Background()) }()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", net.JoinHostPort(info.Host, info.Port))
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, server.Shutdown(context.Background()))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Shutdown")
+	}
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+// knownMethods are the methods recorded as is, the others being _OTHER to bound the cardinality.
+var knownMethods = map[string]struct{}{
+	http.MethodGet: {}, http.MethodHead: {}, http.MethodPost: {}, http.MethodPut: {}, http.MethodPatch: {},
+	http.MethodDelete: {}, http.MethodConnect: {}, http.MethodOptions: {}, http.MethodTrace: {},
+}
+
+// WithExcludedPaths serves the paths, e.g. health checks, without spans nor metrics. The paths match the request path
+// exactly.
+func WithExcludedPaths(paths ...string) Customizer {
+	return func(cs *BaseServer) {
+		for _, p := range paths {
+			cs.excludedPaths[p] = struct{}{}
+		}
+	}
+}
+
+// instrument creates a server span per request, named after its route once it is served, and records the semconv
+// HTTP server metrics. The measurements are made with the request context, so the ones of a sampled trace carry an
+// exemplar pointing at it.
+func instrument(next http.Handler, t *observability.Telemetry, m *serverMetrics, excluded map[string]struct{}) http.Handler {
+	tracer := t.TracerProvider.Tracer(instrumentationName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := excluded[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		method := requestMethod(r)
+		scheme := requestScheme(r)
+
+		activeAttrs := metric.WithAttributes(method, scheme)
+		m.active.Add(r.Context(), 1, activeAttrs)
+		defer m.active.Add(r.Context(), -1, activeAttrs)
+
+		ctx, span := tracer.Start(r.Context(), method.Value.AsString(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(requestAttrs(r, method, scheme)...),
+		)
+		defer span.End()
+
+		body := &countingBody{ReadCloser: r.Body}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
+		rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		responseAttrs := []attribute.KeyValue{semconv.HTTPResponseStatusCode(rw.status)}
+		if route := Route(ctx); route != "" {
+			span.SetName(method.Value.AsString() + " " + route)
+			responseAttrs = append(responseAttrs, semconv.HTTPRoute(route))
+		}
+		if rw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.status))
+			responseAttrs = append(responseAttrs, semconv.ErrorTypeKey.String(strconv.Itoa(rw.status)))
+		}
+		span.SetAttributes(responseAttrs...)
+
+		set := metric.WithAttributeSet(attribute.NewSet(append(responseAttrs, method, scheme)...))
+		m.duration.Record(ctx, time.Since(start).Seconds(), set)
+		m.requestSize.Record(ctx, body.bytes, set)
+		m.responseSize.Record(ctx, rw.bytes, set)
+	})
+}
+
+func requestMethod(r *http.Request) attribute.KeyValue {
+	if _, ok := knownMethods[r.Method]; ok {
+		return semconv.HTTPRequestMethodKey.String(r.Method)
+	}
+	return semconv.HTTPRequestMethodOther
+}
+
+func requestScheme(r *http.Request) attribute.KeyValue {
+	if r.TLS != nil {
+		return semconv.URLScheme("https")
+	}
+	return semconv.URLScheme("http")
+}
+
+// requestAttrs are the span attributes known before the request is served.
+func requestAttrs(r *http.Request, method, scheme attribute.KeyValue) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		method,
+		scheme,
+		semconv.URLPath(r.URL.Path),
+		semconv.NetworkProtocolVersion(protocolVersion(r)),
+		semconv.ClientAddress(ClientIP(r)),
+	}
+	if method == semconv.HTTPRequestMethodOther {
+		attrs = append(attrs, semconv.HTTPRequestMethodOriginal(r.Method))
+	}
+	if host, port, err := net.SplitHostPort(r.Host); err == nil {
+		attrs = append(attrs, semconv.ServerAddress(host))
+		if p, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, semconv.ServerPort(p))
+		}
+	} else if r.Host != "" {
+		attrs = append(attrs, semconv.ServerAddress(r.Host))
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(ua))
+	}
+	return attrs
+}
+
+func protocolVersion(r *http.Request) string {
+	switch r.ProtoMajor {
+	case 1:
+		return "1." + strconv.Itoa(r.ProtoMinor)
+	default:
+		return strconv.Itoa(r.ProtoMajor)
+	}
+}
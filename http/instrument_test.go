@@ -0,0 +1,102 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/davfer/goforarun"
+	gofarhttp "github.com/davfer/goforarun/http"
+	"github.com/davfer/goforarun/observability/observabilitytest"
+)
+
+func TestInstrumentation(t *testing.T) {
+	kit := observabilitytest.Install(t)
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagator) })
+
+	server := gofarhttp.NewHttpBaseServer(&goforarun.InfoServer{Name: "test"}, nil,
+		gofarhttp.WithExcludedPaths("/healthz"))
+	server.HandleFunc("POST /users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("created"))
+	})
+	server.HandleFunc("GET /fail", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	server.HandleFunc("GET /healthz", func(http.ResponseWriter, *http.Request) {})
+	handler, err := server.Handler(context.Background())
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "http://example.com:8080/users/42", strings.NewReader("body"))
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	serve(handler, r)
+	serve(handler, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	serve(handler, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	span := kit.AssertSpan("POST /users/{id}",
+		attribute.String("http.request.method", "POST"),
+		attribute.String("http.route", "/users/{id}"),
+		attribute.String("url.path", "/users/42"),
+		attribute.String("server.address", "example.com"),
+		attribute.Int("server.port", 8080),
+		attribute.Int("http.response.status_code", 200),
+	)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.Parent.TraceID().String())
+
+	failed := kit.AssertSpan("GET /fail", attribute.Int("http.response.status_code", 502))
+	assert.Equal(t, codes.Error, failed.Status.Code)
+	kit.AssertNoSpan("GET /healthz")
+
+	route := attribute.String("http.route", "/users/{id}")
+	kit.AssertCounter("http.server.request.duration", 1, route)
+	kit.AssertCounter("http.server.request.duration", 1, attribute.String("error.type", "502"))
+	kit.AssertCounter("http.server.request.body.size", 1, route)
+	kit.AssertCounter("http.server.response.body.size", 1, route)
+	kit.AssertCounter("http.server.active_requests", 0)
+}
+
+func TestInstrumentationFlushAndHijack(t *testing.T) {
+	observabilitytest.Install(t)
+
+	server := gofarhttp.NewHttpBaseServer(&goforarun.InfoServer{Name: "test"}, nil)
+	server.HandleFunc("GET /stream", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("chunk"))
+		w.(http.Flusher).Flush()
+	})
+	server.HandleFunc("GET /hijack", func(w http.ResponseWriter, _ *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = rw.Flush()
+	})
+	handler, err := server.Handler(context.Background())
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.True(t, rec.Flushed)
+
+	resp, err := ts.Client().Get(ts.URL + "/hijack")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hijacked", string(body))
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// instrumentationName is the name of the tracer and the meter of the server.
+const instrumentationName = "github.com/davfer/goforarun/http"
+
+// durationBuckets are the semconv advisory boundaries of http.server.request.duration, in seconds.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// serverMetrics are the semconv HTTP server metrics.
+type serverMetrics struct {
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+	active       metric.Int64UpDownCounter
+}
+
+func newServerMetrics(provider metric.MeterProvider) (m *serverMetrics, err error) {
+	meter := provider.Meter(instrumentationName)
+	m = &serverMetrics{}
+
+	if m.duration, err = meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	); err != nil {
+		return nil, err
+	}
+	if m.requestSize, err = meter.Int64Histogram("http.server.request.body.size",
+		metric.WithDescription("Size of HTTP server request bodies"),
+		metric.WithUnit("By"),
+	); err != nil {
+		return nil, err
+	}
+	if m.responseSize, err = meter.Int64Histogram("http.server.response.body.size",
+		metric.WithDescription("Size of HTTP server response bodies"),
+		metric.WithUnit("By"),
+	); err != nil {
+		return nil, err
+	}
+	if m.active, err = meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithDescription("Number of active HTTP server requests"),
+		metric.WithUnit("{request}"),
+	); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// statusWriter keeps the status code and counts the bytes written to the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush lets the handlers stream their response through the type assertion to http.Flusher.
+func (w *statusWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets the handlers take over the connection, e.g. for websockets, through the type assertion to
+// http.Hijacker.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// countingBody counts the bytes read from the request body.
+type countingBody struct {
+	io.ReadCloser
+	bytes int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	return n, err
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+// Middleware wraps a handler, running before and after it.
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes the middlewares, the first one being the outermost.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// WithMiddleware appends the middlewares to the chain of the server, the first one being the outermost. The chain
+// runs inside the framework instrumentation, after the routing is tracked, so it can read the Route once the request
+// is served.
+func WithMiddleware(middlewares ...Middleware) Customizer {
+	return func(cs *BaseServer) {
+		cs.middlewares = append(cs.middlewares, middlewares...)
+	}
+}
+
+// MiddlewareConfig enables the built-in middlewares, chained in the order of the fields.
+type MiddlewareConfig struct {
+	// RealIP is the configuration of the client IP read from the headers of the trusted proxies
+	RealIP RealIPConfig `yaml:"real_ip"`
+	// RequestID is the configuration of the request IDs
+	RequestID RequestIDConfig `yaml:"request_id"`
+	// AccessLog is the configuration of the access logs
+	AccessLog AccessLogConfig `yaml:"access_log"`
+	// Recovery answers 500 to the requests whose handler panics, logging the stack
+	Recovery bool `yaml:"recovery"`
+	// MaxBodyBytes is the maximum size of the request bodies, 0 is unlimited
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// Timeout is the maximum duration of a request, answering 503 past it, 0 is unlimited
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// RealIPConfig is the configuration of the RealIP middleware.
+type RealIPConfig struct {
+	// Enabled reads the client IP from the X-Forwarded-For and X-Real-IP headers
+	Enabled bool `yaml:"enabled"`
+	// TrustedProxies are the IPs or CIDRs of the proxies whose headers are trusted
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// RequestIDConfig is the configuration of the RequestID middleware.
+type RequestIDConfig struct {
+	// Enabled propagates the request ID, generating one when the request has none
+	Enabled bool `yaml:"enabled"`
+	// Header is the header carrying the request ID (default X-Request-ID)
+	Header string `yaml:"header"`
+}
+
+// AccessLogConfig is the configuration of the AccessLog middleware.
+type AccessLogConfig struct {
+	// Enabled logs a record per request
+	Enabled bool `yaml:"enabled"`
+	// Channel is the channel of the access logs (default http-access)
+	Channel string `yaml:"channel"`
+}
+
+// Middlewares returns the enabled built-in middlewares, in the order of the config fields, logging with the loggers
+// of the telemetry.
+func (c MiddlewareConfig) Middlewares(t *observability.Telemetry) ([]Middleware, error) {
+	var middlewares []Middleware
+	if c.RealIP.Enabled {
+		realIP, err := RealIP(c.RealIP.TrustedProxies)
+		if err != nil {
+			return nil, err
+		}
+		middlewares = append(middlewares, realIP)
+	}
+	if c.RequestID.Enabled {
+		middlewares = append(middlewares, RequestID(c.RequestID.Header))
+	}
+	if c.AccessLog.Enabled {
+		channel := c.AccessLog.Channel
+		if channel == "" {
+			channel = DefaultAccessLogChannel
+		}
+		middlewares = append(middlewares, AccessLog(t.Logger(channel)))
+	}
+	if c.Recovery {
+		middlewares = append(middlewares, Recovery(t.Logger("http-server")))
+	}
+	if c.MaxBodyBytes < 0 {
+		return nil, fmt.Errorf("invalid max body bytes %d", c.MaxBodyBytes)
+	}
+	if c.MaxBodyBytes > 0 {
+		middlewares = append(middlewares, MaxBodySize(c.MaxBodyBytes))
+	}
+	if c.Timeout > 0 {
+		middlewares = append(middlewares, Timeout(c.Timeout))
+	}
+	return middlewares, nil
+}
@@ -0,0 +1,179 @@
+package http_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thejerf/slogassert"
+
+	gofarhttp "github.com/davfer/goforarun/http"
+	"github.com/davfer/goforarun/observability"
+)
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	mark := func(name string) gofarhttp.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	h := gofarhttp.Chain(mark("first"), mark("second"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls = append(calls, "handler")
+	}))
+	serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestRecovery(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelInfo, nil)
+
+	h := gofarhttp.Recovery(slog.New(handler))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message: "panic serving request",
+		Level:   slog.LevelError,
+		Attrs:   map[string]any{"path": "/panic", "panic": "boom"},
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	var got string
+	h := gofarhttp.RequestID("")(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = gofarhttp.RequestIDFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-ID", "abc")
+	rec := serve(h, r)
+	assert.Equal(t, "abc", got)
+	assert.Equal(t, "abc", rec.Header().Get("X-Request-ID"))
+
+	rec = serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, got, 36)
+	assert.Equal(t, got, rec.Header().Get("X-Request-ID"))
+}
+
+func TestAccessLog(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelInfo, nil)
+
+	h := gofarhttp.Chain(gofarhttp.RequestID(""), gofarhttp.AccessLog(slog.New(handler)))(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("hello"))
+		}))
+	r := httptest.NewRequest(http.MethodPost, "/things", nil)
+	r.Header.Set("X-Request-ID", "abc")
+	serve(h, r)
+
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message: "request served",
+		Level:   slog.LevelInfo,
+		Attrs: map[string]any{
+			"method":     "POST",
+			"path":       "/things",
+			"status":     int64(http.StatusCreated),
+			"bytes":      int64(5),
+			"client_ip":  "192.0.2.1",
+			"request_id": "abc",
+		},
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	h := gofarhttp.Timeout(10 * time.Millisecond)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestMaxBodySize(t *testing.T) {
+	h := gofarhttp.MaxBodySize(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	}))
+
+	rec := serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abc")))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abcdef")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	r := httptest.NewRequest(http.MethodPost, "/", io.MultiReader(strings.NewReader("abcdef")))
+	r.ContentLength = -1
+	rec = serve(h, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestRealIP(t *testing.T) {
+	realIP, err := gofarhttp.RealIP([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+
+	var got string
+	h := realIP(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = gofarhttp.ClientIP(r)
+	}))
+
+	for _, c := range []struct {
+		name, remote, forwarded, realIP, want string
+	}{
+		{"trusted proxy", "192.0.2.1:1234", "203.0.113.7, 10.0.0.2", "", "203.0.113.7"},
+		{"spoofed hop", "192.0.2.1:1234", "198.51.100.9, 203.0.113.7", "", "203.0.113.7"},
+		{"untrusted remote", "198.51.100.9:1234", "203.0.113.7", "", "198.51.100.9"},
+		{"real ip header", "10.1.2.3:1234", "", "203.0.113.8", "203.0.113.8"},
+		{"invalid header", "10.1.2.3:1234", "garbage", "", "10.1.2.3"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = c.remote
+			if c.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", c.forwarded)
+			}
+			if c.realIP != "" {
+				r.Header.Set("X-Real-IP", c.realIP)
+			}
+			serve(h, r)
+			assert.Equal(t, c.want, got)
+		})
+	}
+
+	_, err = gofarhttp.RealIP([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
+
+func TestMiddlewareConfig(t *testing.T) {
+	middlewares, err := gofarhttp.MiddlewareConfig{
+		RequestID:    gofarhttp.RequestIDConfig{Enabled: true},
+		Recovery:     true,
+		MaxBodyBytes: 10,
+	}.Middlewares(observability.Global())
+	require.NoError(t, err)
+	assert.Len(t, middlewares, 3)
+
+	_, err = gofarhttp.MiddlewareConfig{RealIP: gofarhttp.RealIPConfig{Enabled: true, TrustedProxies: []string{"bad"}}}.Middlewares(observability.Global())
+	assert.Error(t, err)
+}
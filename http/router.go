@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type routeCtxKey struct{}
+
+// matchedRoute is the pattern of the handler serving a request, filled in by the router once the request is matched.
+type matchedRoute struct {
+	pattern string
+}
+
+// Handle registers the handler for the pattern, in the http.ServeMux syntax with the optional method and wildcards,
+// e.g. "GET /users/{id}". The pattern is the route of the requests it serves.
+func (cs *BaseServer) Handle(pattern string, handler http.Handler) {
+	cs.mux.Handle(pattern, routed(handler))
+}
+
+// HandleFunc registers the handler function for the pattern, see Handle.
+func (cs *BaseServer) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	cs.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// Route returns the path template of the route serving the request of the context, e.g. /users/{id}, to name its
+// spans and metrics without the cardinality of the paths. It is empty before the request is routed and for the
+// requests matching no route.
+func Route(ctx context.Context) string {
+	m, ok := ctx.Value(routeCtxKey{}).(*matchedRoute)
+	if !ok {
+		return ""
+	}
+	return routeTemplate(m.pattern)
+}
+
+// trackRoute makes the route of the request available to the handlers wrapping the router, which read it with Route
+// once the request has been served.
+func trackRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), routeCtxKey{}, &matchedRoute{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// routed records the pattern the mux matched the request with. A handler being a mux itself sets a more specific
+// pattern on the request, which is recorded once it returns.
+func routed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m, ok := r.Context().Value(routeCtxKey{}).(*matchedRoute)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		m.pattern = r.Pattern
+		next.ServeHTTP(w, r)
+		if r.Pattern != "" {
+			m.pattern = r.Pattern
+		}
+	})
+}
+
+// routeTemplate strips the method and the host of a pattern, "GET example.com/users/{id}" being /users/{id}.
+func routeTemplate(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimLeft(path, " \t")
+	}
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
@@ -0,0 +1,75 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun"
+	gofarhttp "github.com/davfer/goforarun/http"
+	"github.com/davfer/goforarun/observability/observabilitytest"
+)
+
+// routeServer serves the routes of the tests, Route being read by a middleware once the request is served.
+func routeServer(t *testing.T, fallback http.Handler, route *string) http.Handler {
+	captureRoute := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			*route = gofarhttp.Route(r.Context())
+		})
+	}
+
+	nested := http.NewServeMux()
+	nested.HandleFunc("GET /api/orders/{id}", func(http.ResponseWriter, *http.Request) {})
+
+	server := gofarhttp.NewHttpBaseServer(&goforarun.InfoServer{Name: "test"}, fallback,
+		gofarhttp.WithMiddleware(captureRoute))
+	server.HandleFunc("GET /users/{id}", func(http.ResponseWriter, *http.Request) {})
+	server.HandleFunc("POST example.com/users", func(http.ResponseWriter, *http.Request) {})
+	server.Handle("/api/", nested)
+	handler, err := server.Handler(context.Background())
+	require.NoError(t, err)
+	return handler
+}
+
+func TestRoute(t *testing.T) {
+	observabilitytest.Install(t)
+
+	var route string
+	withFallback := routeServer(t, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}), &route)
+	withoutFallback := routeServer(t, nil, &route)
+
+	for _, tc := range []struct {
+		name    string
+		handler http.Handler
+		method  string
+		target  string
+		route   string
+		status  int
+	}{
+		{"method pattern", withoutFallback, http.MethodGet, "/users/42", "/users/{id}", http.StatusOK},
+		{"host pattern", withoutFallback, http.MethodPost, "http://example.com/users", "/users", http.StatusOK},
+		{"nested mux", withoutFallback, http.MethodGet, "/api/orders/7", "/api/orders/{id}", http.StatusOK},
+		{"nested mux unmatched", withoutFallback, http.MethodGet, "/api/unknown", "/api/", http.StatusNotFound},
+		{"unmatched path", withoutFallback, http.MethodGet, "/unknown", "", http.StatusNotFound},
+		{"unmatched method", withoutFallback, http.MethodDelete, "/users/42", "", http.StatusMethodNotAllowed},
+		{"fallback", withFallback, http.MethodGet, "/unknown", "/", http.StatusOK},
+		{"fallback other method", withFallback, http.MethodDelete, "/users/42", "/", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			route = "unset"
+			rec := serve(tc.handler, httptest.NewRequest(tc.method, tc.target, nil))
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.route, route)
+		})
+	}
+}
+
+func TestRouteOutsideServer(t *testing.T) {
+	assert.Empty(t, gofarhttp.Route(context.Background()))
+}
@@ -2,43 +2,142 @@ package http
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/propagation"
 
 	"github.com/davfer/goforarun"
-	"github.com/davfer/goforarun/logger"
+	"github.com/davfer/goforarun/observability"
 )
 
+// BaseServer serves the routes registered with Handle and HandleFunc on an http.ServeMux, instrumented by the
+// framework.
 type BaseServer struct {
-	info       *goforarun.InfoServer
-	httpServer *http.Server
-	logger     *slog.Logger
-	handler    http.HandlerFunc
+	info          *goforarun.InfoServer
+	mu            sync.Mutex
+	httpServer    *http.Server
+	logger        *slog.Logger
+	mux           *http.ServeMux
+	middlewares   []Middleware
+	excludedPaths map[string]struct{}
+	config        Config
 }
 
-func NewHttpBaseServer(info *goforarun.InfoServer, handler http.HandlerFunc) goforarun.RunnableServer {
-	return &BaseServer{
-		info:       info,
-		logger:     logger.Get("http-server", slog.String("name", info.Name)),
-		httpServer: nil,
-		handler:    handler,
+// Customizer configures a BaseServer.
+type Customizer func(*BaseServer)
+
+// NewHttpBaseServer creates a server routing the requests to the handler, which can be nil when the routes are
+// registered with Handle and HandleFunc. The handler serves the requests matching no other route, its route being
+// "/" unless it is a mux itself.
+func NewHttpBaseServer(info *goforarun.InfoServer, handler http.Handler, customizers ...Customizer) *BaseServer {
+	cs := &BaseServer{
+		info:          info,
+		httpServer:    nil,
+		mux:           http.NewServeMux(),
+		excludedPaths: make(map[string]struct{}),
+	}
+	if handler != nil {
+		cs.Handle("/", handler)
 	}
+	for _, customizer := range customizers {
+		customizer(cs)
+	}
+	return cs
 }
 
 func (cs *BaseServer) Run(ctx context.Context) error {
-	cs.logger.With("connection", cs.info).Info("listening server")
-	cs.httpServer = &http.Server{
+	handler, err := cs.Handler(ctx)
+	if err != nil {
+		return err
+	}
+	cs.logger = observability.FromContext(ctx).Logger("http-server", slog.String("name", cs.info.Name))
+
+	server := &http.Server{
 		Addr:    cs.info.Host + ":" + cs.info.Port,
-		Handler: cs.handler,
+		Handler: handler,
 	}
+	cs.config.apply(server)
+	if cs.config.LogConnState {
+		server.ConnState = cs.logConnState
+	}
+
+	var reloader *tlsReloader
+	if cs.config.TLS.Enabled {
+		if reloader, err = newTLSReloader(cs.config.TLS, cs.logger); err != nil {
+			return err
+		}
+		server.TLSConfig = reloader.serverConfig()
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go reloader.watch(watchCtx)
+	}
+
+	cs.mu.Lock()
+	cs.httpServer = server
+	cs.mu.Unlock()
 
-	return cs.httpServer.ListenAndServe()
+	cs.logger.With("connection", cs.info, "tls", reloader != nil).Info("listening server")
+	if reloader != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Handler returns the routes wrapped by the middlewares and the instrumentation, as Run serves them, using the
+// telemetry of the context. It serves the server in tests or mounted on another one.
+func (cs *BaseServer) Handler(ctx context.Context) (http.Handler, error) {
+	telemetry := observability.FromContext(ctx)
+	metrics, err := newServerMetrics(telemetry.MeterProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := instrument(Chain(cs.middlewares...)(cs.mux), telemetry, metrics, cs.excludedPaths)
+	return hideDiagnostics(trackRoute(extractContext(handler, telemetry.Propagator))), nil
 }
 
 func (cs *BaseServer) Shutdown(ctx context.Context) error {
-	return cs.httpServer.Shutdown(ctx)
+	cs.mu.Lock()
+	server := cs.httpServer
+	cs.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
 
 func (cs *BaseServer) Info() *goforarun.InfoServer {
 	return cs.info
 }
+
+// extractContext puts the trace context and baggage propagated in the request headers into the request context,
+// using the propagator of the service telemetry.
+func extractContext(next http.Handler, propagator propagation.TextMapPropagator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// hideDiagnostics answers not found to the pprof and expvar paths, which their packages register on the
+// http.DefaultServeMux. They are served by the admin server only.
+func hideDiagnostics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/debug/pprof") || r.URL.Path == "/debug/vars" {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
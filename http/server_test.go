@@ -0,0 +1,30 @@
+package http_test
+
+import (
+	"context"
+	_ "expvar"
+	"net/http"
+	"net/http/httptest"
+	_ "net/http/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun"
+	gofarhttp "github.com/davfer/goforarun/http"
+	"github.com/davfer/goforarun/observability/observabilitytest"
+)
+
+func TestBaseServerHidesDiagnostics(t *testing.T) {
+	observabilitytest.Install(t)
+
+	// the default mux, serving pprof and expvar, is mounted as the fallback handler
+	server := gofarhttp.NewHttpBaseServer(&goforarun.InfoServer{Name: "test"}, http.DefaultServeMux)
+	handler, err := server.Handler(context.Background())
+	require.NoError(t, err)
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/vars"} {
+		assert.Equal(t, http.StatusNotFound, serve(handler, httptest.NewRequest(http.MethodGet, path, nil)).Code, path)
+	}
+}
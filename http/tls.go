@@ -0,0 +1,233 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/davfer/goforarun/logger"
+)
+
+// DefaultTLSReloadInterval is how often the certificate files are checked when no interval is configured.
+const DefaultTLSReloadInterval = 30 * time.Second
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// TLSConfig is the TLS configuration of a BaseServer. The certificate, its key and the client CA bundle are reloaded
+// when their files change, so rotated certificates are served without restarting the server.
+type TLSConfig struct {
+	// Enabled serves HTTPS
+	Enabled bool `yaml:"enabled"`
+	// CertFile is the PEM certificate chain of the server
+	CertFile string `yaml:"cert_file"`
+	// KeyFile is the PEM private key of the certificate
+	KeyFile string `yaml:"key_file"`
+	// MinVersion is the minimum TLS version accepted (1.0, 1.1, 1.2, 1.3), default 1.2
+	MinVersion string `yaml:"min_version"`
+	// CipherSuites are the names of the TLS 1.0-1.2 cipher suites accepted, default the Go secure ones. TLS 1.3
+	// suites are not configurable
+	CipherSuites []string `yaml:"cipher_suites"`
+	// ClientCAFile is the PEM bundle of the CAs verifying the client certificates
+	ClientCAFile string `yaml:"client_ca_file"`
+	// ClientAuth is the client certificate policy (none, request, require, verify_if_given, require_and_verify),
+	// default require_and_verify with a client CA and none otherwise
+	ClientAuth string `yaml:"client_auth"`
+	// ReloadInterval is how often the files are checked for changes (default 30s), a negative one disables the reload
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// tlsReloader serves the certificate and the client CAs last loaded from the files of its config.
+type tlsReloader struct {
+	config     TLSConfig
+	minVersion uint16
+	ciphers    []uint16
+	clientAuth tls.ClientAuthType
+	logger     *slog.Logger
+
+	current atomic.Pointer[tls.Config]
+	stamps  []fileStamp
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func newTLSReloader(c TLSConfig, l *slog.Logger) (*tlsReloader, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("tls needs a cert file and a key file")
+	}
+
+	r := &tlsReloader{config: c, minVersion: tls.VersionTLS12, logger: l}
+	if c.MinVersion != "" {
+		v, ok := tlsVersions[c.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown tls min version %s", c.MinVersion)
+		}
+		r.minVersion = v
+	}
+
+	secure := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		secure[s.Name] = s.ID
+	}
+	for _, name := range c.CipherSuites {
+		id, ok := secure[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure tls cipher suite %s", name)
+		}
+		r.ciphers = append(r.ciphers, id)
+	}
+
+	if c.ClientCAFile != "" {
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	}
+	if c.ClientAuth != "" {
+		auth, ok := clientAuthTypes[c.ClientAuth]
+		if !ok {
+			return nil, fmt.Errorf("unknown tls client auth %s", c.ClientAuth)
+		}
+		r.clientAuth = auth
+	}
+	if r.clientAuth >= tls.VerifyClientCertIfGiven && c.ClientCAFile == "" {
+		return nil, fmt.Errorf("tls client auth %s needs a client ca file", c.ClientAuth)
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// serverConfig is the config of the server, handing the last loaded one to every connection. GetCertificate lets
+// ListenAndServeTLS start without certificate files.
+func (r *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.minVersion,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current.Load().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// load reads the files and replaces the config served.
+func (r *tlsReloader) load() error {
+	stamps, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("could not load tls certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   r.minVersion,
+		CipherSuites: r.ciphers,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("could not read tls client ca: %w", err)
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("no certificate found in tls client ca file")
+		}
+	}
+
+	r.current.Store(config)
+	r.stamps = stamps
+	return nil
+}
+
+func (r *tlsReloader) stat() ([]fileStamp, error) {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+
+	stamps := make([]fileStamp, 0, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tls file: %w", err)
+		}
+		stamps = append(stamps, fileStamp{info.ModTime(), info.Size()})
+	}
+	return stamps, nil
+}
+
+// watch reloads the files when they change until ctx is done. A failed reload keeps serving the previous files, as
+// the certificate and its key may be caught halfway through their rotation.
+func (r *tlsReloader) watch(ctx context.Context) {
+	interval := r.config.ReloadInterval
+	if interval < 0 {
+		return
+	}
+	if interval == 0 {
+		interval = DefaultTLSReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stamps, err := r.stat()
+			if err != nil {
+				r.logger.Error("could not check tls files", logger.AttrErr(err))
+				continue
+			}
+			if r.unchanged(stamps) {
+				continue
+			}
+			if err = r.load(); err != nil {
+				r.logger.Error("could not reload tls files", logger.AttrErr(err))
+				continue
+			}
+			attrs := []any{slog.String("cert_file", r.config.CertFile)}
+			if leaf := r.current.Load().Certificates[0].Leaf; leaf != nil {
+				attrs = append(attrs, slog.Time("not_after", leaf.NotAfter))
+			}
+			r.logger.Info("reloaded tls files", attrs...)
+		}
+	}
+}
+
+func (r *tlsReloader) unchanged(stamps []fileStamp) bool {
+	for i := range stamps {
+		if !stamps[i].modTime.Equal(r.stamps[i].modTime) || stamps[i].size != r.stamps[i].size {
+			return false
+		}
+	}
+	return len(stamps) == len(r.stamps)
+}
@@ -0,0 +1,158 @@
+package http_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun"
+	gofarhttp "github.com/davfer/goforarun/http"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a leaf signed by the CA.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	require.NoError(t, os.WriteFile(path+".tmp", content, 0o600))
+	require.NoError(t, os.Rename(path+".tmp", path))
+}
+
+func TestTLSReloadAndClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	cert, key := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	writeFile(t, keyFile, key)
+	writeFile(t, caFile, ca.pem)
+
+	info := &goforarun.InfoServer{Host: "127.0.0.1", Port: freePort(t), Name: "tls"}
+	server := gofarhttp.NewHttpBaseServer(info, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}), gofarhttp.WithConfig(gofarhttp.Config{TLS: gofarhttp.TLSConfig{
+		Enabled:        true,
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		MinVersion:     "1.3",
+		ClientCAFile:   caFile,
+		ReloadInterval: 10 * time.Millisecond,
+	}}))
+	go func() { _ = server.Run(context.Background()) }()
+	defer server.Shutdown(context.Background())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert, clientKey := ca.issue(t, 20, x509.ExtKeyUsageClientAuth)
+	clientPair, err := tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+
+	addr := net.JoinHostPort(info.Host, info.Port)
+	servedSerial := func(certs []tls.Certificate) (int64, error) {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, Certificates: certs})
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+		if err = conn.Handshake(); err != nil {
+			return 0, err
+		}
+		// TLS 1.3 reports a rejected client certificate on the first read
+		if _, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+			return 0, err
+		}
+		if _, err = conn.Read(make([]byte, 1)); err != nil {
+			return 0, err
+		}
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+	}
+
+	require.Eventually(t, func() bool {
+		serial, err := servedSerial([]tls.Certificate{clientPair})
+		return err == nil && serial == 10
+	}, 2*time.Second, 10*time.Millisecond)
+
+	_, err = servedSerial(nil)
+	assert.Error(t, err, "a client without certificate is rejected")
+
+	cert, key = ca.issue(t, 11, x509.ExtKeyUsageServerAuth)
+	writeFile(t, keyFile, key)
+	writeFile(t, certFile, cert)
+	assert.Eventually(t, func() bool {
+		serial, err := servedSerial([]tls.Certificate{clientPair})
+		return err == nil && serial == 11
+	}, 2*time.Second, 10*time.Millisecond, "the rotated certificate is served")
+}
+
+func TestTLSInvalidConfig(t *testing.T) {
+	for name, c := range map[string]gofarhttp.TLSConfig{
+		"no files":      {Enabled: true},
+		"min version":   {Enabled: true, CertFile: "a", KeyFile: "b", MinVersion: "2.0"},
+		"cipher":        {Enabled: true, CertFile: "a", KeyFile: "b", CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+		"client auth":   {Enabled: true, CertFile: "a", KeyFile: "b", ClientAuth: "require_and_verify"},
+		"missing files": {Enabled: true, CertFile: "a", KeyFile: "b"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			info := &goforarun.InfoServer{Host: "127.0.0.1", Port: freePort(t)}
+			server := gofarhttp.NewHttpBaseServer(info, nil, gofarhttp.WithConfig(gofarhttp.Config{TLS: c}))
+			assert.Error(t, server.Run(context.Background()))
+		})
+	}
+}
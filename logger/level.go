@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Levels on top of the slog ones. They keep the slog spacing so the OTel bridge maps them to the TRACE and FATAL
+// severities.
+const (
+	LevelTrace = slog.Level(-8)
+	LevelFatal = slog.Level(12)
+	// LevelPanic is the highest level, mapped to the last OTel FATAL severity
+	LevelPanic = slog.Level(15)
+)
+
+// fatalFlushTimeout bounds the time Fatal waits for the hook before exiting.
+const fatalFlushTimeout = 5 * time.Second
+
+var levelNames = map[slog.Level]string{
+	LevelTrace:      "TRACE",
+	slog.LevelDebug: "DEBUG",
+	slog.LevelInfo:  "INFO",
+	slog.LevelWarn:  "WARN",
+	slog.LevelError: "ERROR",
+	LevelFatal:      "FATAL",
+	LevelPanic:      "PANIC",
+}
+
+var fatalHook func(context.Context) error
+
+// LevelName returns the name of the level, including the framework ones (TRACE, FATAL, PANIC).
+func LevelName(l slog.Level) string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return l.String()
+}
+
+// ReplaceLevelName is a slog.HandlerOptions ReplaceAttr function that writes the framework level names.
+func ReplaceLevelName(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String(slog.LevelKey, LevelName(l))
+		}
+	}
+	return a
+}
+
+// ParseLevel parses a level name (trace, debug, info, warn, error, fatal, panic) case-insensitively. Numeric levels
+// and slog offsets like "info+2" are accepted too.
+func ParseLevel(s string) (slog.Level, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	for l, n := range levelNames {
+		if n == name {
+			return l, nil
+		}
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		return slog.Level(n), nil
+	}
+
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return l, fmt.Errorf("level %s not supported", s)
+	}
+	return l, nil
+}
+
+// SetFatalHook sets the function called by Fatal before exiting, usually to flush the observability pipeline.
+func SetFatalHook(hook func(context.Context) error) {
+	fatalHook = hook
+}
+
+// Fatal logs the message at LevelFatal, runs the fatal hook and exits the process with status 1.
+func Fatal(l *slog.Logger, msg string, args ...any) {
+	l.Log(context.Background(), LevelFatal, msg, args...)
+
+	if fatalHook != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+		if err := fatalHook(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "could not flush before exiting: %v\n", err)
+		}
+		cancel()
+	}
+
+	os.Exit(1)
+}
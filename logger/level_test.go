@@ -0,0 +1,51 @@
+package logger_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun/logger"
+)
+
+func TestParseLevel(t *testing.T) {
+	testCases := map[string]slog.Level{
+		"trace":  logger.LevelTrace,
+		"DEBUG":  slog.LevelDebug,
+		"Info":   slog.LevelInfo,
+		"warn":   slog.LevelWarn,
+		"error":  slog.LevelError,
+		"fatal":  logger.LevelFatal,
+		"panic":  logger.LevelPanic,
+		"-2":     slog.Level(-2),
+		"12":     logger.LevelFatal,
+		"info+2": slog.Level(2),
+	}
+
+	for s, expected := range testCases {
+		t.Run(s, func(t *testing.T) {
+			l, err := logger.ParseLevel(s)
+			require.NoError(t, err)
+			assert.Equal(t, expected, l)
+		})
+	}
+
+	_, err := logger.ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestReplaceLevelName(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: logger.LevelTrace, ReplaceAttr: logger.ReplaceLevelName}))
+
+	l.Log(t.Context(), logger.LevelTrace, "tracing")
+	l.Log(t.Context(), logger.LevelFatal, "dying")
+	l.Log(t.Context(), slog.LevelWarn+1, "odd")
+
+	assert.Contains(t, buf.String(), "level=TRACE msg=tracing")
+	assert.Contains(t, buf.String(), "level=FATAL msg=dying")
+	assert.Contains(t, buf.String(), "level=WARN+1 msg=odd")
+}
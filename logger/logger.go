@@ -65,3 +65,44 @@ func (c *ChanneledHandler) WithGroup(name string) slog.Handler {
 		channels: c.channels,
 	}
 }
+
+var _ slog.Handler = (*LeveledHandler)(nil)
+
+// LeveledHandler drops the records below a minimum level before they reach the wrapped handler. The records dumped
+// by a RingBufferHandler are always accepted.
+type LeveledHandler struct {
+	wrap  slog.Handler
+	level slog.Leveler
+}
+
+func NewLeveledHandler(h slog.Handler, level slog.Leveler) slog.Handler {
+	return &LeveledHandler{
+		wrap:  h,
+		level: level,
+	}
+}
+
+func (l *LeveledHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if IsReplay(ctx) {
+		return true
+	}
+	return level >= l.level.Level() && l.wrap.Enabled(ctx, level)
+}
+
+func (l *LeveledHandler) Handle(ctx context.Context, record slog.Record) error {
+	return l.wrap.Handle(ctx, record)
+}
+
+func (l *LeveledHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LeveledHandler{
+		wrap:  l.wrap.WithAttrs(attrs),
+		level: l.level,
+	}
+}
+
+func (l *LeveledHandler) WithGroup(name string) slog.Handler {
+	return &LeveledHandler{
+		wrap:  l.wrap.WithGroup(name),
+		level: l.level,
+	}
+}
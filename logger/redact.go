@@ -0,0 +1,349 @@
+package logger
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RedactedValue is the default replacement of the redacted values.
+const RedactedValue = "[REDACTED]"
+
+// redactMaxDepth stops the walk of self-referencing values.
+const redactMaxDepth = 10
+
+// DefaultRedactedKeys are the attribute keys whose values are always redacted by the default policy.
+var DefaultRedactedKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie", "set_cookie",
+	"api_key", "apikey", "access_token", "refresh_token", "client_secret", "private_key",
+}
+
+// DefaultRedactedPatterns are the value patterns redacted by the default policy.
+var DefaultRedactedPatterns = map[string]*regexp.Regexp{
+	"email":       regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+	"card_number": regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+	"jwt":         regexp.MustCompile(`\beyJ[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]*`),
+}
+
+// RedactionPolicy describes what the RedactingHandler hides. Keys are matched case-insensitively, with dashes
+// and underscores being equivalent, either as the whole key or as its last underscore separated part.
+type RedactionPolicy struct {
+	Keys        []string
+	Patterns    []*regexp.Regexp
+	Replacement string
+}
+
+// DefaultRedactionPolicy returns a policy with the DefaultRedactedKeys and DefaultRedactedPatterns.
+func DefaultRedactionPolicy() RedactionPolicy {
+	p := RedactionPolicy{
+		Keys:        append([]string{}, DefaultRedactedKeys...),
+		Replacement: RedactedValue,
+	}
+	for _, re := range DefaultRedactedPatterns {
+		p.Patterns = append(p.Patterns, re)
+	}
+	return p
+}
+
+var _ slog.Handler = (*RedactingHandler)(nil)
+
+// RedactingHandler hides sensitive data from the records before they reach the wrapped handler. It redacts
+// attributes by key, string values and messages by pattern, and struct fields tagged `log:"redact"`. Structs and
+// maps logged with slog.Any are turned into groups so every nested level is redacted.
+type RedactingHandler struct {
+	wrap     slog.Handler
+	redactor *redactor
+}
+
+type redactor struct {
+	keys        map[string]struct{}
+	patterns    []*regexp.Regexp
+	replacement string
+}
+
+func NewRedactingHandler(h slog.Handler, policy RedactionPolicy) slog.Handler {
+	return &RedactingHandler{
+		wrap:     h,
+		redactor: newRedactor(policy),
+	}
+}
+
+// RedactTree returns a copy of a tree of maps, slices and scalars, like the ones decoded from JSON or YAML, with the
+// values of the matching keys replaced and the patterns redacted from the strings.
+func (p RedactionPolicy) RedactTree(v any) any {
+	return newRedactor(p).redactTree(v, 0)
+}
+
+func newRedactor(policy RedactionPolicy) *redactor {
+	r := &redactor{
+		keys:        make(map[string]struct{}, len(policy.Keys)),
+		patterns:    policy.Patterns,
+		replacement: policy.Replacement,
+	}
+	if r.replacement == "" {
+		r.replacement = RedactedValue
+	}
+	for _, k := range policy.Keys {
+		r.keys[normalizeKey(k)] = struct{}{}
+	}
+	return r
+}
+
+func (r *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return r.wrap.Enabled(ctx, level)
+}
+
+func (r *RedactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, r.redactor.redactString(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(r.redactor.redactAttr(attr, 0))
+		return true
+	})
+
+	return r.wrap.Handle(ctx, redacted)
+}
+
+func (r *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = r.redactor.redactAttr(attr, 0)
+	}
+	return &RedactingHandler{
+		wrap:     r.wrap.WithAttrs(redacted),
+		redactor: r.redactor,
+	}
+}
+
+func (r *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{
+		wrap:     r.wrap.WithGroup(name),
+		redactor: r.redactor,
+	}
+}
+
+func (r *redactor) redactAttr(attr slog.Attr, depth int) slog.Attr {
+	if r.matchKey(attr.Key) {
+		return slog.String(attr.Key, r.replacement)
+	}
+	return slog.Attr{Key: attr.Key, Value: r.redactValue(attr.Value, depth)}
+}
+
+func (r *redactor) redactValue(v slog.Value, depth int) slog.Value {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(r.redactString(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, a := range group {
+			attrs[i] = r.redactAttr(a, depth+1)
+		}
+		return slog.GroupValue(attrs...)
+	case slog.KindAny:
+		// fail closed, what is too deep to be inspected is not logged
+		if depth >= redactMaxDepth {
+			return slog.StringValue(r.replacement)
+		}
+		return r.redactAny(v, depth)
+	default:
+		return v
+	}
+}
+
+func (r *redactor) redactAny(v slog.Value, depth int) slog.Value {
+	rv := reflect.ValueOf(v.Any())
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return v
+		}
+		rv = rv.Elem()
+	}
+
+	// the structs with log tags are walked whatever their methods, the tags being the explicit choice of their author
+	if rv.Kind() != reflect.Struct || !hasLogTags(rv.Type()) {
+		switch a := v.Any().(type) {
+		case error:
+			return slog.StringValue(r.redactString(a.Error()))
+		case fmt.Stringer:
+			return slog.StringValue(r.redactString(a.String()))
+		case json.Marshaler:
+			return r.redactJSON(a, v, depth)
+		case encoding.TextMarshaler:
+			text, err := a.MarshalText()
+			if err != nil {
+				return v
+			}
+			return slog.StringValue(r.redactString(string(text)))
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		var attrs []slog.Attr
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := fieldName(field)
+			if name == "-" || field.Tag.Get("log") == "-" {
+				continue
+			}
+			if field.Tag.Get("log") == "redact" {
+				attrs = append(attrs, slog.String(name, r.replacement))
+				continue
+			}
+			attrs = append(attrs, r.redactAttr(slog.Any(name, rv.Field(i).Interface()), depth+1))
+		}
+		return slog.GroupValue(attrs...)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		attrs := make([]slog.Attr, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			attrs = append(attrs, r.redactAttr(slog.Any(iter.Key().String(), iter.Value().Interface()), depth+1))
+		}
+		return slog.GroupValue(attrs...)
+	case reflect.Slice, reflect.Array:
+		switch rv.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer, reflect.Interface:
+		case reflect.String:
+			values := make([]string, rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				values[i] = r.redactString(rv.Index(i).String())
+			}
+			return slog.AnyValue(values)
+		default:
+			return v
+		}
+		attrs := make([]slog.Attr, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			attrs[i] = slog.Attr{Key: strconv.Itoa(i), Value: r.redactValue(slog.AnyValue(rv.Index(i).Interface()), depth+1)}
+		}
+		return slog.GroupValue(attrs...)
+	case reflect.String:
+		return slog.StringValue(r.redactString(rv.String()))
+	default:
+		return v
+	}
+}
+
+// redactJSON redacts the JSON output of a marshaler, as it would be logged by the JSON handler. The value is left
+// as is when it cannot be marshalled, for the handler to report the error.
+func (r *redactor) redactJSON(m json.Marshaler, v slog.Value, depth int) slog.Value {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return v
+	}
+	var tree any
+	if err = json.Unmarshal(data, &tree); err != nil {
+		return v
+	}
+	return slog.AnyValue(r.redactTree(tree, depth))
+}
+
+func (r *redactor) redactTree(v any, depth int) any {
+	if depth > redactMaxDepth {
+		return r.replacement
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			if r.matchKey(k) {
+				m[k] = r.replacement
+			} else {
+				m[k] = r.redactTree(e, depth+1)
+			}
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = r.redactTree(e, depth+1)
+		}
+		return s
+	case string:
+		return r.redactString(v)
+	default:
+		return v
+	}
+}
+
+func (r *redactor) redactString(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, r.replacement)
+	}
+	return s
+}
+
+func (r *redactor) matchKey(key string) bool {
+	if len(r.keys) == 0 {
+		return false
+	}
+	key = normalizeKey(key)
+	// every suffix of the segments is matched, x_api_key matching api_key as well as key
+	for {
+		if _, ok := r.keys[key]; ok {
+			return true
+		}
+		i := strings.IndexByte(key, '_')
+		if i < 0 {
+			return false
+		}
+		key = key[i+1:]
+	}
+}
+
+// normalizeKey lowers the key and separates its segments with _, whether they were separated by -, _ or camelCase
+// (privateKey, X-API-Key and APIKey becoming private_key, x_api_key and api_key).
+func normalizeKey(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, c := range runes {
+		switch {
+		case c == '-' || c == '_':
+			b.WriteByte('_')
+			continue
+		case unicode.IsUpper(c) && i > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+// hasLogTags tells whether a field of the struct has a log tag.
+func hasLogTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("log"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
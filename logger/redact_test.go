@@ -0,0 +1,199 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thejerf/slogassert"
+
+	"github.com/davfer/goforarun/logger"
+)
+
+type credentials struct {
+	User     string `json:"user"`
+	Password string
+	Pin      string `log:"redact"`
+}
+
+type loginRequest struct {
+	Credentials *credentials
+	Headers     map[string][]string
+	Notes       []string
+}
+
+func TestRedactingHandler(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+	l := slog.New(logger.NewRedactingHandler(handler, logger.DefaultRedactionPolicy()))
+
+	l.With("api_key", "secret-key").Info("login from john@example.com",
+		slog.Any("request", loginRequest{
+			Credentials: &credentials{User: "john", Password: "hunter2", Pin: "1234"},
+			Headers:     map[string][]string{"Authorization": {"Bearer abc"}, "Accept": {"*/*"}},
+			Notes:       []string{"card 4111 1111 1111 1111"},
+		}),
+		slog.Group("session", slog.String("refresh-token", "xyz"), slog.String("jwt", "eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl")),
+		slog.Int("attempt", 1),
+	)
+
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message: "login from [REDACTED]",
+		Level:   slog.LevelInfo,
+		Attrs: map[string]any{
+			"api_key":                       logger.RedactedValue,
+			"request.Credentials.user":      "john",
+			"request.Credentials.Password":  logger.RedactedValue,
+			"request.Credentials.Pin":       logger.RedactedValue,
+			"request.Headers.Authorization": logger.RedactedValue,
+			"request.Notes": func(v any) bool {
+				return assert.Equal(t, []string{"card [REDACTED]"}, v)
+			},
+			"session.refresh-token": logger.RedactedValue,
+			"session.jwt":           logger.RedactedValue,
+			"attempt":               int64(1),
+		},
+		AllAttrsMatch: false,
+	})
+}
+
+func TestRedactingHandlerCustomPolicy(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+	l := slog.New(logger.NewRedactingHandler(handler, logger.RedactionPolicy{
+		Keys:        []string{"ssn"},
+		Replacement: "***",
+	}))
+
+	l.Info("user john@example.com", slog.String("ssn", "123-45-6789"), slog.String("password", "kept"))
+
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message: "user john@example.com",
+		Level:   slog.LevelInfo,
+		Attrs: map[string]any{
+			"ssn":      "***",
+			"password": "kept",
+		},
+		AllAttrsMatch: true,
+	})
+}
+
+func TestRedactionPolicyRedactTree(t *testing.T) {
+	tree := map[string]any{
+		"service_name": "api",
+		"database":     map[string]any{"user": "app", "password": "hunter2"},
+		"admin":        map[string]any{"token": "abc", "allowed_networks": []any{"10.0.0.0/8"}},
+		"contacts":     []any{"ops@example.com"},
+		"port":         8080,
+	}
+
+	assert.Equal(t, map[string]any{
+		"service_name": "api",
+		"database":     map[string]any{"user": "app", "password": "[REDACTED]"},
+		"admin":        map[string]any{"token": "[REDACTED]", "allowed_networks": []any{"10.0.0.0/8"}},
+		"contacts":     []any{"[REDACTED]"},
+		"port":         8080,
+	}, logger.DefaultRedactionPolicy().RedactTree(tree))
+	assert.Equal(t, "hunter2", tree["database"].(map[string]any)["password"])
+}
+
+type card struct {
+	Holder string `json:"holder"`
+	Number string `json:"number" log:"redact"`
+}
+
+func (c card) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"holder": c.Holder, "number": c.Number})
+}
+
+type session struct {
+	ID    string
+	Token string
+}
+
+func (s session) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"id": s.ID, "token": s.Token})
+}
+
+type userID string
+
+func (u userID) MarshalText() ([]byte, error) {
+	return []byte("user " + string(u)), nil
+}
+
+func TestRedactingHandlerMarshalers(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+	l := slog.New(logger.NewRedactingHandler(handler, logger.DefaultRedactionPolicy()))
+
+	l.Info("paid",
+		slog.Any("card", card{Holder: "john", Number: "4111 1111 1111 1111"}),
+		slog.Any("session", &session{ID: "1", Token: "xyz"}),
+		slog.Any("user", userID("john@example.com")),
+	)
+
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message: "paid",
+		Level:   slog.LevelInfo,
+		Attrs: map[string]any{
+			"card.holder": "john",
+			"card.number": logger.RedactedValue,
+			"session": func(v any) bool {
+				return assert.Equal(t, map[string]any{"id": "1", "token": logger.RedactedValue}, v)
+			},
+			"user": "user [REDACTED]",
+		},
+		AllAttrsMatch: true,
+	})
+}
+
+func TestRedactingHandlerKeyForms(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+	l := slog.New(logger.NewRedactingHandler(handler, logger.DefaultRedactionPolicy()))
+
+	l.Info("request",
+		slog.String("x-api-key", "s3cr3t"),
+		slog.String("X-API-Key", "s3cr3t"),
+		slog.String("privateKey", "s3cr3t"),
+		slog.String("APIKey", "s3cr3t"),
+		slog.String("dbPassword", "s3cr3t"),
+		slog.String("user-agent", "curl"),
+		slog.String("keyId", "1"),
+	)
+
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message: "request",
+		Level:   slog.LevelInfo,
+		Attrs: map[string]any{
+			"x-api-key":  logger.RedactedValue,
+			"X-API-Key":  logger.RedactedValue,
+			"privateKey": logger.RedactedValue,
+			"APIKey":     logger.RedactedValue,
+			"dbPassword": logger.RedactedValue,
+			"user-agent": "curl",
+			"keyId":      "1",
+		},
+		AllAttrsMatch: true,
+	})
+}
+
+type deepNode struct {
+	Password string
+	Next     *deepNode
+}
+
+func TestRedactingHandlerMaxDepth(t *testing.T) {
+	var root *deepNode
+	for i := 12; i >= 0; i-- {
+		root = &deepNode{Password: "pw" + strconv.Itoa(i), Next: root}
+	}
+
+	var buf bytes.Buffer
+	l := slog.New(logger.NewRedactingHandler(slog.NewJSONHandler(&buf, nil), logger.RedactionPolicy{
+		Keys: []string{"password"},
+	}))
+	l.Info("deep", slog.Any("node", root))
+
+	assert.NotContains(t, buf.String(), `"pw`)
+	assert.Contains(t, buf.String(), logger.RedactedValue)
+}
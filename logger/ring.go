@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+type replayCtxKey struct{}
+
+// ReplayAttrKey is the attribute added to the records written by a RingBufferHandler dump.
+const ReplayAttrKey = "replayed"
+
+var _ slog.Handler = (*RingBufferHandler)(nil)
+
+// RingBufferHandler keeps the last records rejected by the wrapped handler, at every level, in a fixed size ring.
+// The ring is written to the wrapped handler when Dump is called or when a record at or above the dump level
+// arrives, giving the debug context of a failure while running at a higher level.
+//
+// The sinks below the wrapped handler need to filter their level with a LeveledHandler, otherwise they drop the
+// dumped records.
+type RingBufferHandler struct {
+	wrap slog.Handler
+	ring *ring
+}
+
+type ringEntry struct {
+	handler slog.Handler
+	record  slog.Record
+}
+
+type ring struct {
+	slots     []atomic.Pointer[ringEntry]
+	next      atomic.Uint64
+	dumpLevel slog.Leveler
+}
+
+// NewRingBufferHandler creates a RingBufferHandler of size records. A nil dumpLevel only dumps on Dump calls.
+func NewRingBufferHandler(h slog.Handler, size int, dumpLevel slog.Leveler) *RingBufferHandler {
+	if size < 1 {
+		size = 1
+	}
+	return &RingBufferHandler{
+		wrap: h,
+		ring: &ring{
+			slots:     make([]atomic.Pointer[ringEntry], size),
+			dumpLevel: dumpLevel,
+		},
+	}
+}
+
+func (r *RingBufferHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (r *RingBufferHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !r.wrap.Enabled(ctx, record.Level) {
+		r.ring.push(&ringEntry{handler: r.wrap, record: record.Clone()})
+		return nil
+	}
+
+	if r.ring.dumpLevel != nil && record.Level >= r.ring.dumpLevel.Level() {
+		r.Dump(ctx)
+	}
+
+	return r.wrap.Handle(ctx, record)
+}
+
+func (r *RingBufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &RingBufferHandler{
+		wrap: r.wrap.WithAttrs(attrs),
+		ring: r.ring,
+	}
+}
+
+func (r *RingBufferHandler) WithGroup(name string) slog.Handler {
+	return &RingBufferHandler{
+		wrap: r.wrap.WithGroup(name),
+		ring: r.ring,
+	}
+}
+
+// Dump writes the buffered records, oldest first, to the wrapped handler and empties the ring. Each record is
+// written once even if several dumps run concurrently.
+func (r *RingBufferHandler) Dump(ctx context.Context) {
+	ctx = context.WithValue(ctx, replayCtxKey{}, true)
+
+	next := r.ring.next.Load()
+	size := uint64(len(r.ring.slots))
+	for i := uint64(0); i < size; i++ {
+		entry := r.ring.slots[(next+i)%size].Swap(nil)
+		if entry == nil {
+			continue
+		}
+
+		entry.record.AddAttrs(slog.Bool(ReplayAttrKey, true))
+		_ = entry.handler.Handle(ctx, entry.record)
+	}
+}
+
+func (r *ring) push(e *ringEntry) {
+	i := r.next.Add(1) - 1
+	r.slots[i%uint64(len(r.slots))].Store(e)
+}
+
+// IsReplay reports whether the context belongs to a RingBufferHandler dump.
+func IsReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayCtxKey{}).(bool)
+	return replay
+}
@@ -0,0 +1,69 @@
+package logger_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thejerf/slogassert"
+
+	"github.com/davfer/goforarun/logger"
+)
+
+func TestRingBufferHandler(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+	ring := logger.NewRingBufferHandler(logger.NewLeveledHandler(handler, slog.LevelInfo), 3, slog.LevelError)
+	l := slog.New(ring)
+
+	l.Debug("debug 1")
+	l.Debug("debug 2")
+	l.With("channel", "db").Debug("debug 3")
+	l.Debug("debug 4")
+	l.Info("info")
+
+	handler.AssertMessage("info")
+	handler.AssertEmpty()
+
+	l.Error("failed")
+
+	var msgs []string
+	for _, r := range handler.Unasserted() {
+		msgs = append(msgs, r.Message)
+	}
+	// the oldest record was overwritten and the buffer is written before the trigger
+	assert.Equal(t, []string{"debug 2", "debug 3", "debug 4", "failed"}, msgs)
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message:       "debug 3",
+		Level:         slog.LevelDebug,
+		Attrs:         map[string]any{"channel": "db", logger.ReplayAttrKey: true},
+		AllAttrsMatch: true,
+	})
+	handler.Reset()
+
+	// already dumped records are not written twice
+	ring.Dump(context.Background())
+	handler.AssertEmpty()
+}
+
+func TestRingBufferHandlerConcurrent(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+	ring := logger.NewRingBufferHandler(logger.NewLeveledHandler(handler, slog.LevelInfo), 64, nil)
+	l := slog.New(ring)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Debug("concurrent")
+			}
+		}()
+	}
+	wg.Wait()
+
+	ring.Dump(context.Background())
+	assert.Len(t, handler.Unasserted(), 64)
+}
@@ -0,0 +1,297 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SamplingMode is the algorithm used by the SamplingHandler to decide if a record is kept.
+type SamplingMode string
+
+const (
+	// SamplingModeBurst keeps the first N records of a key per interval and then every Mth.
+	SamplingModeBurst SamplingMode = "burst"
+	// SamplingModeTokenBucket keeps records while the key has tokens, refilled at a fixed rate per second.
+	SamplingModeTokenBucket SamplingMode = "token_bucket"
+)
+
+// SamplingAnyChannel is the channel key used for channels without their own policy.
+const SamplingAnyChannel = "*"
+
+// SamplingChannel is the channel of the summary records emitted by the SamplingHandler.
+const SamplingChannel = "log-sampler"
+
+// DefaultSamplingReportInterval is the summary interval used when none is given.
+const DefaultSamplingReportInterval = time.Minute
+
+// SamplingPolicy describes how records of a channel are sampled. Records are keyed on level and message.
+type SamplingPolicy struct {
+	// Mode is the sampling algorithm, burst or token bucket
+	Mode SamplingMode
+	// First is the number of records kept per interval in burst mode
+	First int
+	// Thereafter keeps every Mth record after First in burst mode, 0 drops them all
+	Thereafter int
+	// Interval is the window of burst mode
+	Interval time.Duration
+	// Rate is the number of tokens added per second in token bucket mode
+	Rate float64
+	// Burst is the capacity of the bucket in token bucket mode
+	Burst int
+}
+
+// Validate checks the policy has the settings required by its mode.
+func (p SamplingPolicy) Validate() error {
+	switch p.Mode {
+	case SamplingModeBurst:
+		if p.First < 0 || p.Thereafter < 0 {
+			return fmt.Errorf("sampling first and thereafter must be positive")
+		}
+		if p.Interval <= 0 {
+			return fmt.Errorf("sampling interval must be greater than zero")
+		}
+	case SamplingModeTokenBucket:
+		if p.Rate <= 0 || p.Burst <= 0 {
+			return fmt.Errorf("sampling rate and burst must be greater than zero")
+		}
+	default:
+		return fmt.Errorf("sampling mode %s not supported", p.Mode)
+	}
+	return nil
+}
+
+var _ slog.Handler = (*SamplingHandler)(nil)
+
+// SamplingHandler drops repeated records of the same channel, level and message according to a per channel policy.
+// Dropped records are counted and reported in a summary record every report interval, by a goroutine running until
+// Close is called.
+type SamplingHandler struct {
+	wrap    slog.Handler
+	channel string
+	sampler *sampler
+}
+
+type samplingKey struct {
+	channel string
+	level   slog.Level
+	msg     string
+}
+
+type samplingCounter struct {
+	windowStart time.Time
+	seen        int
+	tokens      float64
+	lastRefill  time.Time
+	lastSeen    time.Time
+	suppressed  int
+}
+
+type sampler struct {
+	mu             sync.Mutex
+	policies       map[string]SamplingPolicy
+	counters       map[samplingKey]*samplingCounter
+	report         slog.Handler
+	reportInterval time.Duration
+	lastReport     time.Time
+	suppressed     metric.Int64Counter
+	now            func() time.Time
+	stop           chan struct{}
+	stopOnce       sync.Once
+}
+
+func NewSamplingHandler(h slog.Handler, policies map[string]SamplingPolicy, reportInterval time.Duration) slog.Handler {
+	if reportInterval <= 0 {
+		reportInterval = DefaultSamplingReportInterval
+	}
+
+	s := &sampler{
+		policies:       policies,
+		counters:       make(map[samplingKey]*samplingCounter),
+		report:         h.WithAttrs([]slog.Attr{slog.String("channel", SamplingChannel)}),
+		reportInterval: reportInterval,
+		lastReport:     time.Now(),
+		now:            time.Now,
+		stop:           make(chan struct{}),
+	}
+	go s.run()
+
+	return &SamplingHandler{
+		wrap:    h,
+		sampler: s,
+	}
+}
+
+func (s *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.wrap.Enabled(ctx, level)
+}
+
+func (s *SamplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	keep := s.sampler.allow(ctx, samplingKey{channel: s.channel, level: record.Level, msg: record.Message})
+	s.sampler.flush(ctx, false)
+	if !keep {
+		return nil
+	}
+
+	return s.wrap.Handle(ctx, record)
+}
+
+func (s *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	channel := s.channel
+	for _, attr := range attrs {
+		if attr.Key == "channel" {
+			channel = attr.Value.String()
+		}
+	}
+	return &SamplingHandler{
+		wrap:    s.wrap.WithAttrs(attrs),
+		channel: channel,
+		sampler: s.sampler,
+	}
+}
+
+func (s *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{
+		wrap:    s.wrap.WithGroup(name),
+		channel: s.channel,
+		sampler: s.sampler,
+	}
+}
+
+// RegisterMetrics counts the suppressed records in the gofar.log.suppressed metric of the provider. It is called
+// before the handler is used.
+func (s *SamplingHandler) RegisterMetrics(provider metric.MeterProvider) error {
+	suppressed, err := provider.Meter("github.com/davfer/goforarun/logger").Int64Counter(
+		"gofar.log.suppressed",
+		metric.WithDescription("Number of log records dropped by sampling"),
+		metric.WithUnit("{record}"),
+	)
+	if err != nil {
+		return fmt.Errorf("could not create suppressed metric: %w", err)
+	}
+	s.sampler.suppressed = suppressed
+	return nil
+}
+
+// Flush emits the summary of the suppressed records right away.
+func (s *SamplingHandler) Flush(ctx context.Context) {
+	s.sampler.flush(ctx, true)
+}
+
+// Close stops the periodic summaries and emits the one of the records suppressed since the last report.
+func (s *SamplingHandler) Close(ctx context.Context) {
+	s.sampler.stopOnce.Do(func() { close(s.sampler.stop) })
+	s.sampler.flush(ctx, true)
+}
+
+// run emits the summary every report interval, so the suppressed records are reported when nothing is logged.
+func (s *sampler) run() {
+	ticker := time.NewTicker(s.reportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.flush(context.Background(), false)
+		}
+	}
+}
+
+func (s *sampler) allow(ctx context.Context, key samplingKey) bool {
+	policy, ok := s.policies[key.channel]
+	if !ok {
+		policy, ok = s.policies[SamplingAnyChannel]
+	}
+	if !ok {
+		return true
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &samplingCounter{windowStart: now, lastRefill: now, tokens: float64(policy.Burst)}
+		s.counters[key] = counter
+	}
+	counter.lastSeen = now
+
+	var keep bool
+	switch policy.Mode {
+	case SamplingModeBurst:
+		if now.Sub(counter.windowStart) >= policy.Interval {
+			counter.windowStart = now
+			counter.seen = 0
+		}
+		counter.seen++
+		keep = counter.seen <= policy.First ||
+			(policy.Thereafter > 0 && (counter.seen-policy.First)%policy.Thereafter == 0)
+	case SamplingModeTokenBucket:
+		counter.tokens += now.Sub(counter.lastRefill).Seconds() * policy.Rate
+		if counter.tokens > float64(policy.Burst) {
+			counter.tokens = float64(policy.Burst)
+		}
+		counter.lastRefill = now
+		if counter.tokens >= 1 {
+			counter.tokens--
+			keep = true
+		}
+	default:
+		keep = true
+	}
+	if !keep {
+		counter.suppressed++
+	}
+	s.mu.Unlock()
+
+	if !keep && s.suppressed != nil {
+		s.suppressed.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("channel", key.channel),
+			attribute.String("level", key.level.String()),
+		))
+	}
+
+	return keep
+}
+
+func (s *sampler) flush(ctx context.Context, force bool) {
+	s.mu.Lock()
+	now := s.now()
+	if !force && now.Sub(s.lastReport) < s.reportInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastReport = now
+
+	var records []slog.Record
+	for key, counter := range s.counters {
+		if counter.suppressed > 0 {
+			r := slog.NewRecord(now, slog.LevelWarn, "log records suppressed by sampling", 0)
+			r.AddAttrs(
+				slog.String("sampled_channel", key.channel),
+				slog.String("sampled_level", key.level.String()),
+				slog.String("sampled_msg", key.msg),
+				slog.Int("suppressed", counter.suppressed),
+			)
+			records = append(records, r)
+			counter.suppressed = 0
+			continue
+		}
+		// forget idle keys so distinct messages do not grow the map forever
+		if now.Sub(counter.lastSeen) >= s.reportInterval {
+			delete(s.counters, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, r := range records {
+		if s.report.Enabled(ctx, r.Level) {
+			_ = s.report.Handle(ctx, r)
+		}
+	}
+}
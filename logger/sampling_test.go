@@ -0,0 +1,154 @@
+package logger_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thejerf/slogassert"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/davfer/goforarun/logger"
+)
+
+func TestSamplingHandlerBurst(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+
+	sampled := logger.NewSamplingHandler(handler, map[string]logger.SamplingPolicy{
+		"hot": {Mode: logger.SamplingModeBurst, First: 3, Thereafter: 5, Interval: time.Hour},
+	}, time.Hour)
+	l := slog.New(sampled)
+
+	for i := 0; i < 20; i++ {
+		l.With("channel", "hot").Error("loop failed")
+		l.With("channel", "cold").Error("loop failed")
+	}
+
+	var hot, cold int
+	for _, r := range handler.Unasserted() {
+		switch r.Attrs["channel"].String() {
+		case "hot":
+			hot++
+		case "cold":
+			cold++
+		}
+	}
+	// 3 first ones, then the 8th, 13th and 18th
+	assert.Equal(t, 6, hot)
+	assert.Equal(t, 20, cold)
+
+	sampled.(*logger.SamplingHandler).Flush(context.Background())
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message: "log records suppressed by sampling",
+		Level:   slog.LevelWarn,
+		Attrs: map[string]any{
+			"channel":         logger.SamplingChannel,
+			"sampled_channel": "hot",
+			"sampled_msg":     "loop failed",
+			"suppressed":      int64(14),
+		},
+		AllAttrsMatch: false,
+	})
+}
+
+func TestSamplingHandlerTokenBucket(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+
+	l := slog.New(logger.NewSamplingHandler(handler, map[string]logger.SamplingPolicy{
+		logger.SamplingAnyChannel: {Mode: logger.SamplingModeTokenBucket, Rate: 0.001, Burst: 2},
+	}, time.Hour))
+
+	for i := 0; i < 10; i++ {
+		l.With("channel", "any").Info("tick")
+		l.With("channel", "any").Warn("tick")
+	}
+
+	var info, warn int
+	for _, r := range handler.Unasserted() {
+		switch r.Level {
+		case slog.LevelInfo:
+			info++
+		case slog.LevelWarn:
+			warn++
+		}
+	}
+	assert.Equal(t, 2, info)
+	assert.Equal(t, 2, warn)
+}
+
+func TestSamplingPolicyValidate(t *testing.T) {
+	assert.NoError(t, logger.SamplingPolicy{Mode: logger.SamplingModeBurst, First: 1, Interval: time.Second}.Validate())
+	assert.NoError(t, logger.SamplingPolicy{Mode: logger.SamplingModeTokenBucket, Rate: 1, Burst: 1}.Validate())
+	assert.Error(t, logger.SamplingPolicy{Mode: logger.SamplingModeBurst, First: 1}.Validate())
+	assert.Error(t, logger.SamplingPolicy{Mode: logger.SamplingModeTokenBucket, Rate: 1}.Validate())
+	assert.Error(t, logger.SamplingPolicy{Mode: "random"}.Validate())
+}
+
+func TestSamplingHandlerReportsWithoutNewRecords(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+
+	sampled := logger.NewSamplingHandler(handler, map[string]logger.SamplingPolicy{
+		"hot": {Mode: logger.SamplingModeBurst, First: 1, Interval: time.Hour},
+	}, 20*time.Millisecond)
+	defer sampled.(*logger.SamplingHandler).Close(context.Background())
+	l := slog.New(sampled).With("channel", "hot")
+
+	for i := 0; i < 5; i++ {
+		l.Error("loop failed")
+	}
+
+	summary := slogassert.LogMessageMatch{
+		Message: "log records suppressed by sampling",
+		Level:   slog.LevelWarn,
+		Attrs:   map[string]any{"sampled_channel": "hot", "suppressed": int64(4)},
+	}
+	assert.Eventually(t, func() bool {
+		return handler.Assert(summary.Matches) > 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestSamplingHandlerCloseReports(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+
+	sampled := logger.NewSamplingHandler(handler, map[string]logger.SamplingPolicy{
+		"hot": {Mode: logger.SamplingModeBurst, First: 1, Interval: time.Hour},
+	}, time.Hour)
+	l := slog.New(sampled).With("channel", "hot")
+	l.Error("loop failed")
+	l.Error("loop failed")
+
+	sampled.(*logger.SamplingHandler).Close(context.Background())
+	handler.AssertPrecise(slogassert.LogMessageMatch{
+		Message: "log records suppressed by sampling",
+		Level:   slog.LevelWarn,
+		Attrs:   map[string]any{"sampled_channel": "hot", "suppressed": int64(1)},
+	})
+}
+
+func TestSamplingHandlerMetrics(t *testing.T) {
+	handler := slogassert.New(t, slog.LevelDebug, nil)
+	reader := metric.NewManualReader()
+
+	sampled := logger.NewSamplingHandler(handler, map[string]logger.SamplingPolicy{
+		"hot": {Mode: logger.SamplingModeBurst, First: 1, Interval: time.Hour},
+	}, time.Hour).(*logger.SamplingHandler)
+	require.NoError(t, sampled.RegisterMetrics(metric.NewMeterProvider(metric.WithReader(reader))))
+	defer sampled.Close(context.Background())
+
+	l := slog.New(sampled).With("channel", "hot")
+	for range 3 {
+		l.Error("loop failed")
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	assert.Equal(t, "gofar.log.suppressed", rm.ScopeMetrics[0].Metrics[0].Name)
+	assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+}
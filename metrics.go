@@ -0,0 +1,78 @@
+package goforarun
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// serviceMetrics are the framework metrics of the service lifecycle.
+type serviceMetrics struct {
+	starts           metric.Int64Counter
+	runningServers   metric.Int64UpDownCounter
+	shutdownDuration metric.Float64Histogram
+}
+
+// newServiceMetrics creates the framework metrics, counting the start of the service. The restarts of a service
+// are the increases of gofar.service.starts over its instances.
+func newServiceMetrics(provider metric.MeterProvider, buildInfo *BuildInfo) (*serviceMetrics, error) {
+	meter := provider.Meter(AppLoggerName)
+	startedAt := time.Now()
+	m := &serviceMetrics{}
+
+	_, err := meter.Float64ObservableCounter("gofar.service.uptime",
+		metric.WithDescription("Time since the service started"), metric.WithUnit("s"),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			o.Observe(time.Since(startedAt).Seconds())
+			return nil
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("could not create uptime metric: %w", err)
+	}
+
+	var buildAttrs []attribute.KeyValue
+	if buildInfo != nil {
+		buildAttrs = append(buildAttrs,
+			attribute.String("version", buildInfo.Version),
+			attribute.String("commit", buildInfo.Commit),
+			attribute.String("date", buildInfo.Date),
+		)
+	}
+	_, err = meter.Int64ObservableGauge("gofar.build_info",
+		metric.WithDescription("Build of the running service, always 1"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, metric.WithAttributes(buildAttrs...))
+			return nil
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("could not create build info metric: %w", err)
+	}
+
+	if m.starts, err = meter.Int64Counter("gofar.service.starts",
+		metric.WithDescription("Number of times the service started")); err != nil {
+		return nil, fmt.Errorf("could not create starts metric: %w", err)
+	}
+	if m.runningServers, err = meter.Int64UpDownCounter("gofar.servers.running",
+		metric.WithDescription("Number of servers running"), metric.WithUnit("{server}")); err != nil {
+		return nil, fmt.Errorf("could not create running servers metric: %w", err)
+	}
+	if m.shutdownDuration, err = meter.Float64Histogram("gofar.service.shutdown.duration",
+		metric.WithDescription("Duration of the soft shutdown of the service"), metric.WithUnit("s")); err != nil {
+		return nil, fmt.Errorf("could not create shutdown duration metric: %w", err)
+	}
+
+	m.starts.Add(context.Background(), 1)
+	return m, nil
+}
+
+// serverStarted counts the server as running until the returned function is called.
+func (m *serviceMetrics) serverStarted(ctx context.Context, name string) func() {
+	attrs := metric.WithAttributes(attribute.String("server", name))
+	m.runningServers.Add(ctx, 1, attrs)
+	return func() {
+		m.runningServers.Add(ctx, -1, attrs)
+	}
+}
@@ -1,7 +1,16 @@
 package goforarun
 
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/davfer/goforarun/logger"
+	"github.com/davfer/goforarun/observability"
+)
+
 type LoggingConfig struct {
-	// Level is the log level (debug, info, warn, error, fatal, panic)
+	// Level is the log level (trace, debug, info, warn, error, fatal, panic), case-insensitive or numeric
 	Level string `yaml:"level"`
 	// Format is the log format (text, json)
 	Format string `yaml:"format"`
@@ -9,4 +18,341 @@ type LoggingConfig struct {
 	Output string `yaml:"output"`
 	// FilteredChannels is the list of channels to filter [channel: level]
 	FilteredChannels map[string]string `yaml:"filtered_channels"`
+	// Sampling drops repeated records of hot channels before they reach the sinks
+	Sampling LogSamplingConfig `yaml:"sampling"`
+	// Redaction hides sensitive data from the records of every sink
+	Redaction LogRedactionConfig `yaml:"redaction"`
+	// CrashBuffer keeps the records below the log level in memory and writes them when the service crashes. It also
+	// applies the log level to the OTLP sink, which otherwise receives every record
+	CrashBuffer LogCrashBufferConfig `yaml:"crash_buffer"`
+}
+
+// LogCrashBufferConfig is the configuration of the in-memory ring of the last records filtered out by the level.
+type LogCrashBufferConfig struct {
+	// Size is the number of records kept, 0 disables the buffer
+	Size int `yaml:"size"`
+	// DumpLevel also writes the buffer when a record at or above this level arrives (empty dumps only on crash)
+	DumpLevel string `yaml:"dump_level"`
+}
+
+// LogRedactionConfig is the redaction configuration of the logs. When enabled, the default keys (password, token,
+// authorization...) and patterns (emails, card numbers, JWTs) are redacted along with the configured ones.
+type LogRedactionConfig struct {
+	// Enabled turns on the redaction of the logs
+	Enabled bool `yaml:"enabled"`
+	// SkipDefaults only redacts the configured keys and patterns
+	SkipDefaults bool `yaml:"skip_defaults"`
+	// Keys are the attribute keys whose values are redacted, case-insensitive
+	Keys []string `yaml:"keys"`
+	// Patterns are regular expressions of the values to redact
+	Patterns []string `yaml:"patterns"`
+	// Replacement is the text written instead of the redacted values (default [REDACTED])
+	Replacement string `yaml:"replacement"`
+}
+
+// policy builds the redaction policy of the config, used by the logs and the admin config endpoint.
+func (c LogRedactionConfig) policy() (logger.RedactionPolicy, error) {
+	policy := logger.RedactionPolicy{}
+	if !c.SkipDefaults {
+		policy = logger.DefaultRedactionPolicy()
+	}
+	policy.Keys = append(policy.Keys, c.Keys...)
+	for _, p := range c.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return logger.RedactionPolicy{}, fmt.Errorf("invalid redaction pattern %s: %w", p, err)
+		}
+		policy.Patterns = append(policy.Patterns, re)
+	}
+	policy.Replacement = c.Replacement
+	return policy, nil
+}
+
+// LogSamplingConfig is the sampling configuration of the logs, keyed by channel. The "*" channel applies to every
+// channel without its own policy.
+type LogSamplingConfig struct {
+	// ReportInterval is how often a summary of the suppressed records is logged (default 1m)
+	ReportInterval time.Duration `yaml:"report_interval"`
+	// Channels is the sampling policy per channel [channel: policy]
+	Channels map[string]LogSamplingPolicy `yaml:"channels"`
+}
+
+// LogSamplingPolicy is the sampling policy of a channel, records are keyed on level and message.
+type LogSamplingPolicy struct {
+	// Mode is the sampling algorithm (burst, token_bucket)
+	Mode string `yaml:"mode"`
+	// First is the number of records let through per interval in burst mode
+	First int `yaml:"first"`
+	// Thereafter lets every Mth record through after First in burst mode
+	Thereafter int `yaml:"thereafter"`
+	// Interval is the window of burst mode
+	Interval time.Duration `yaml:"interval"`
+	// Rate is the number of records per second let through in token_bucket mode
+	Rate float64 `yaml:"rate"`
+	// Burst is the number of records let through at once in token_bucket mode
+	Burst int `yaml:"burst"`
+}
+
+// ObservabilityConfig is the configuration of the telemetry signals and their exporters. Values left empty fall back
+// to the standard OTEL_* environment variables.
+type ObservabilityConfig struct {
+	// OTLP is the exporter configuration shared by every signal
+	OTLP OTLPConfig `yaml:"otlp"`
+	// Traces is the configuration of the traces signal
+	Traces TracesConfig `yaml:"traces"`
+	// Metrics is the configuration of the metrics signal
+	Metrics MetricsConfig `yaml:"metrics"`
+	// Logs is the configuration of the logs signal
+	Logs LogsConfig `yaml:"logs"`
+	// Propagators are the context propagation formats (tracecontext, baggage, b3, b3multi, jaeger, none), defaults
+	// to OTEL_PROPAGATORS or tracecontext and baggage
+	Propagators []string `yaml:"propagators"`
+	// Globals sets the providers and the logger as the otel and slog globals (default true)
+	Globals *bool `yaml:"globals"`
+	// Prometheus serves the metrics to be scraped, alongside the metrics exporter
+	Prometheus PrometheusConfig `yaml:"prometheus"`
+	// FailurePolicy is what happens when an exporter cannot be created (fail, degrade), defaults to fail
+	FailurePolicy string `yaml:"failure_policy"`
+	// Fallback is the exporter of the degraded signals (none, stdout), defaults to none
+	Fallback string `yaml:"fallback"`
+	// StartupCheck dials the OTLP collectors at startup with this timeout, failing their exporters if unreachable
+	StartupCheck time.Duration `yaml:"startup_check"`
+}
+
+// SignalConfig is the configuration of a single telemetry signal.
+type SignalConfig struct {
+	// Enabled turns the signal on or off (default true)
+	Enabled *bool `yaml:"enabled"`
+	// Exporter is where the signal is sent (otlp, stdout, file, none), defaults to OTEL_{SIGNAL}_EXPORTER or otlp
+	Exporter string `yaml:"exporter"`
+	// File is the path written by the file exporter, as JSON lines
+	File string `yaml:"file"`
+	// Pretty indents the output of the stdout exporter (default true in debug mode)
+	Pretty *bool `yaml:"pretty"`
+	// OTLP overrides the shared OTLP configuration for this signal
+	OTLP OTLPConfig `yaml:"otlp"`
+}
+
+func (c SignalConfig) customizers(signal observability.Signal) []observability.Customizer {
+	opts := []observability.Customizer{observability.WithSignalOTLP(signal, c.OTLP.options())}
+	if c.Enabled != nil && !*c.Enabled {
+		opts = append(opts, observability.WithSignalExporter(signal, observability.ExporterNone))
+	} else if c.Exporter != "" {
+		opts = append(opts, observability.WithSignalExporter(signal, observability.ExporterKind(c.Exporter)))
+	}
+	if c.File != "" {
+		opts = append(opts, observability.WithSignalFile(signal, c.File))
+	}
+	if c.Pretty != nil {
+		opts = append(opts, observability.WithSignalPrettyPrint(signal, *c.Pretty))
+	}
+	return opts
+}
+
+// TracesConfig is the configuration of the traces signal.
+type TracesConfig struct {
+	SignalConfig `yaml:",inline"`
+	// Sampler decides which traces are recorded, defaults to OTEL_TRACES_SAMPLER or always_on
+	Sampler SamplerConfig `yaml:"sampler"`
+	// Batch tunes the batch processor of the spans
+	Batch BatchConfig `yaml:"batch"`
+}
+
+// LogsConfig is the configuration of the logs signal.
+type LogsConfig struct {
+	SignalConfig `yaml:",inline"`
+	// Batch tunes the batch processor of the logs
+	Batch BatchConfig `yaml:"batch"`
+}
+
+func (c LogsConfig) customizers() []observability.Customizer {
+	return append(c.SignalConfig.customizers(observability.SignalLogs), observability.WithSignalBatch(observability.SignalLogs, c.Batch.options()))
+}
+
+// BatchConfig tunes a batch processor, the empty values keep the SDK defaults.
+type BatchConfig struct {
+	// QueueSize is the number of items buffered before dropping them
+	QueueSize int `yaml:"queue_size"`
+	// BatchSize is the maximum number of items of an export
+	BatchSize int `yaml:"batch_size"`
+	// ExportTimeout bounds each export
+	ExportTimeout time.Duration `yaml:"export_timeout"`
+	// ScheduleDelay is the time between exports of a partial batch
+	ScheduleDelay time.Duration `yaml:"schedule_delay"`
+}
+
+func (c BatchConfig) options() observability.BatchOptions {
+	return observability.BatchOptions{
+		QueueSize:     c.QueueSize,
+		BatchSize:     c.BatchSize,
+		ExportTimeout: c.ExportTimeout,
+		ScheduleDelay: c.ScheduleDelay,
+	}
+}
+
+// SamplerConfig is the configuration of the traces sampler.
+type SamplerConfig struct {
+	// Type is the sampler (always_on, always_off, traceidratio, ratelimited, and their parentbased_ variants)
+	Type string `yaml:"type"`
+	// Arg is the ratio of the traceidratio samplers (default 1) or the traces per second of the ratelimited ones
+	Arg *float64 `yaml:"arg"`
+	// Rules override the sampler of some spans, e.g. to never sample health checks
+	Rules []SamplerRuleConfig `yaml:"rules"`
+}
+
+// SamplerRuleConfig overrides the sampler of the spans matching both span name and route, the first match wins.
+type SamplerRuleConfig struct {
+	// SpanName is a pattern of the span name (path.Match syntax)
+	SpanName string `yaml:"span_name"`
+	// Route is a pattern of the http.route or url.path attribute (path.Match syntax), whose {name} and trailing
+	// {name...} wildcards also match the url.path of the requests, e.g. /users/{id}
+	Route string `yaml:"route"`
+	// Type is the sampler of the matching spans
+	Type string `yaml:"type"`
+	// Arg is the argument of the sampler of the matching spans
+	Arg *float64 `yaml:"arg"`
+}
+
+func (c TracesConfig) customizers() []observability.Customizer {
+	sampler := observability.SamplerOptions{Type: c.Sampler.Type, Arg: c.Sampler.Arg}
+	for _, r := range c.Sampler.Rules {
+		sampler.Rules = append(sampler.Rules, observability.SamplerRule{SpanName: r.SpanName, Route: r.Route, Type: r.Type, Arg: r.Arg})
+	}
+	return append(c.SignalConfig.customizers(observability.SignalTraces),
+		observability.WithSampler(sampler),
+		observability.WithSignalBatch(observability.SignalTraces, c.Batch.options()),
+	)
+}
+
+// MetricsConfig is the configuration of the metrics signal.
+type MetricsConfig struct {
+	SignalConfig `yaml:",inline"`
+	// Interval is the time between collections, defaults to OTEL_METRIC_EXPORT_INTERVAL or a minute
+	Interval time.Duration `yaml:"interval"`
+	// Timeout bounds each export, defaults to OTEL_METRIC_EXPORT_TIMEOUT or 30 seconds
+	Timeout time.Duration `yaml:"timeout"`
+	// Runtime turns the Go runtime and process metrics on or off (default true)
+	Runtime *bool `yaml:"runtime"`
+	// ExemplarFilter selects the measurements linked to their trace (trace_based, always_on, always_off), defaults to
+	// OTEL_METRICS_EXEMPLAR_FILTER or trace_based
+	ExemplarFilter string `yaml:"exemplar_filter"`
+}
+
+func (c MetricsConfig) customizers() []observability.Customizer {
+	opts := c.SignalConfig.customizers(observability.SignalMetrics)
+	if c.Interval > 0 {
+		opts = append(opts, observability.WithMetricsInterval(c.Interval))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, observability.WithMetricsTimeout(c.Timeout))
+	}
+	if c.Runtime != nil {
+		opts = append(opts, observability.WithRuntimeMetrics(*c.Runtime))
+	}
+	if c.ExemplarFilter != "" {
+		opts = append(opts, observability.WithExemplarFilter(c.ExemplarFilter))
+	}
+	return opts
+}
+
+// PrometheusConfig is the configuration of the Prometheus scrape endpoint.
+type PrometheusConfig struct {
+	// Enabled attaches the Prometheus reader and starts its server
+	Enabled bool `yaml:"enabled"`
+	// Host is the interface the server listens on, all of them when empty
+	Host string `yaml:"host"`
+	// Port is the port the server listens on (default 9464)
+	Port string `yaml:"port"`
+	// Path is the path of the metrics (default /metrics)
+	Path string `yaml:"path"`
+}
+
+// OTLPConfig is the configuration of an OTLP exporter.
+type OTLPConfig struct {
+	// Protocol is the transport (grpc, http/protobuf)
+	Protocol string `yaml:"protocol"`
+	// Endpoint is the collector address, either host:port or a URL
+	Endpoint string `yaml:"endpoint"`
+	// Headers are sent with every export request, e.g. for authentication
+	Headers map[string]string `yaml:"headers"`
+	// Insecure disables TLS, false on a signal turning it back on when the shared configuration disables it
+	Insecure *bool `yaml:"insecure"`
+	// Compression is the payload compression (none, gzip)
+	Compression string `yaml:"compression"`
+	// TLS is the TLS configuration of the connection to the collector
+	TLS OTLPTLSConfig `yaml:"tls"`
+	// Timeout bounds each export request
+	Timeout time.Duration `yaml:"timeout"`
+	// Retry is the backoff of the failed exports, the exporter defaults when not set
+	Retry *OTLPRetryConfig `yaml:"retry"`
+}
+
+// OTLPRetryConfig is the backoff of the failed OTLP exports.
+type OTLPRetryConfig struct {
+	// Enabled turns the retries on or off (default true)
+	Enabled *bool `yaml:"enabled"`
+	// InitialInterval is the wait before the first retry
+	InitialInterval time.Duration `yaml:"initial_interval"`
+	// MaxInterval caps the exponential wait between retries
+	MaxInterval time.Duration `yaml:"max_interval"`
+	// MaxElapsedTime is the time after which the data is dropped
+	MaxElapsedTime time.Duration `yaml:"max_elapsed_time"`
+}
+
+// OTLPTLSConfig is the TLS configuration of an OTLP exporter.
+type OTLPTLSConfig struct {
+	// CAFile is the CA bundle used to verify the collector
+	CAFile string `yaml:"ca_file"`
+	// CertFile is the client certificate, for mTLS
+	CertFile string `yaml:"cert_file"`
+	// KeyFile is the client certificate key, for mTLS
+	KeyFile string `yaml:"key_file"`
+	// InsecureSkipVerify does not verify the collector certificate
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+func (c OTLPConfig) options() observability.OTLPOptions {
+	var retry *observability.RetryOptions
+	if c.Retry != nil {
+		retry = &observability.RetryOptions{
+			Enabled:         c.Retry.Enabled == nil || *c.Retry.Enabled,
+			InitialInterval: c.Retry.InitialInterval,
+			MaxInterval:     c.Retry.MaxInterval,
+			MaxElapsedTime:  c.Retry.MaxElapsedTime,
+		}
+	}
+	return observability.OTLPOptions{
+		Protocol:           c.Protocol,
+		Endpoint:           c.Endpoint,
+		Headers:            c.Headers,
+		Insecure:           c.Insecure,
+		Compression:        c.Compression,
+		CAFile:             c.TLS.CAFile,
+		CertFile:           c.TLS.CertFile,
+		KeyFile:            c.TLS.KeyFile,
+		InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+		Timeout:            c.Timeout,
+		Retry:              retry,
+	}
+}
+
+// customizers returns the observability options of the configuration.
+func (c ObservabilityConfig) customizers() []observability.Customizer {
+	opts := []observability.Customizer{observability.WithOTLP(c.OTLP.options())}
+	opts = append(opts, c.Traces.customizers()...)
+	opts = append(opts, c.Metrics.customizers()...)
+	opts = append(opts, c.Logs.customizers()...)
+	if len(c.Propagators) > 0 {
+		opts = append(opts, observability.WithPropagators(c.Propagators...))
+	}
+	if c.Prometheus.Enabled {
+		opts = append(opts, observability.WithPrometheus(true))
+	}
+	if c.FailurePolicy != "" {
+		opts = append(opts, observability.WithFailurePolicy(observability.FailurePolicy(c.FailurePolicy), observability.ExporterKind(c.Fallback)))
+	}
+	if c.StartupCheck > 0 {
+		opts = append(opts, observability.WithStartupCheck(c.StartupCheck))
+	}
+	return opts
 }
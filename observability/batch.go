@@ -0,0 +1,162 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// defaultQueueSize is the queue size of the SDK batch processors when neither the options nor the env vars set it.
+const defaultQueueSize = 2048
+
+// BatchOptions tune the batch processor of the spans or the logs. The zero values keep the SDK defaults, which read
+// the OTEL_BSP_* and OTEL_BLRP_* env vars.
+type BatchOptions struct {
+	// QueueSize is the number of items buffered before dropping them
+	QueueSize int
+	// BatchSize is the maximum number of items of an export
+	BatchSize int
+	// ExportTimeout bounds each export
+	ExportTimeout time.Duration
+	// ScheduleDelay is the time between exports of a partial batch
+	ScheduleDelay time.Duration
+}
+
+// WithSignalBatch tunes the batch processor of the traces or the logs.
+func WithSignalBatch(signal Signal, o BatchOptions) Customizer {
+	return func(c *Cfg) {
+		if c.signalBatch == nil {
+			c.signalBatch = make(map[Signal]BatchOptions)
+		}
+		c.signalBatch[signal] = o
+	}
+}
+
+// WithMetricsTimeout bounds each metrics export, defaults to OTEL_METRIC_EXPORT_TIMEOUT or 30 seconds.
+func WithMetricsTimeout(timeout time.Duration) Customizer {
+	return func(c *Cfg) {
+		c.metricsTimeout = timeout
+	}
+}
+
+// queueSize resolves the queue size of the batch processor of the signal as the SDK does, from the options, then the
+// env var, to bound the queueGate with it.
+func (c *Cfg) queueSize(signal Signal, env string) int {
+	if size := c.signalBatch[signal].QueueSize; size > 0 {
+		return size
+	}
+	if size, err := strconv.Atoi(os.Getenv(env)); err == nil && size > 0 {
+		return size
+	}
+	return defaultQueueSize
+}
+
+func (c *Cfg) spanProcessorOptions() (opts []trace.BatchSpanProcessorOption) {
+	o := c.signalBatch[SignalTraces]
+	opts = append(opts, trace.WithMaxQueueSize(c.queueSize(SignalTraces, "OTEL_BSP_MAX_QUEUE_SIZE")))
+	if o.BatchSize > 0 {
+		opts = append(opts, trace.WithMaxExportBatchSize(o.BatchSize))
+	}
+	if o.ExportTimeout > 0 {
+		opts = append(opts, trace.WithExportTimeout(o.ExportTimeout))
+	}
+	if o.ScheduleDelay > 0 {
+		opts = append(opts, trace.WithBatchTimeout(o.ScheduleDelay))
+	}
+	return
+}
+
+func (c *Cfg) logProcessorOptions() (opts []log.BatchProcessorOption) {
+	o := c.signalBatch[SignalLogs]
+	opts = append(opts, log.WithMaxQueueSize(c.queueSize(SignalLogs, "OTEL_BLRP_MAX_QUEUE_SIZE")))
+	if o.BatchSize > 0 {
+		opts = append(opts, log.WithExportMaxBatchSize(o.BatchSize))
+	}
+	if o.ExportTimeout > 0 {
+		opts = append(opts, log.WithExportTimeout(o.ExportTimeout))
+	}
+	if o.ScheduleDelay > 0 {
+		opts = append(opts, log.WithExportInterval(o.ScheduleDelay))
+	}
+	return
+}
+
+// queueGate drops the items that would not fit in the queue of the batch processor before they reach it, as the SDK
+// drops them without counting them. The pending items, from their admission to the end of their export, are never
+// fewer than the queued ones, so the SDK queue does not fill up behind the gate.
+type queueGate struct {
+	size  int64
+	stats *exportStats
+}
+
+func (g queueGate) admit() bool {
+	if g.stats.pending.Add(1) > g.size {
+		g.stats.pending.Add(-1)
+		g.stats.dropped.Add(1)
+		return false
+	}
+	return true
+}
+
+// gatedSpanProcessor puts a queueGate in front of a batch span processor.
+type gatedSpanProcessor struct {
+	trace.SpanProcessor
+	gate queueGate
+}
+
+func (p *gatedSpanProcessor) OnEnd(s trace.ReadOnlySpan) {
+	// the batch processor only queues the sampled spans
+	if s.SpanContext().IsSampled() && !p.gate.admit() {
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// gatedLogProcessor puts a queueGate in front of a batch log processor.
+type gatedLogProcessor struct {
+	log.Processor
+	gate queueGate
+}
+
+func (p *gatedLogProcessor) OnEmit(ctx context.Context, record *log.Record) error {
+	if !p.gate.admit() {
+		return nil
+	}
+	return p.Processor.OnEmit(ctx, record)
+}
+
+// registerExportMetrics publishes the counts of the exported and dropped spans and logs. The dropped ones are those
+// that did not fit in the queue and those whose export failed, including the retries.
+func (t *Telemetry) registerExportMetrics() error {
+	meter := t.MeterProvider.Meter("github.com/davfer/goforarun/observability")
+	exported, err := meter.Int64ObservableCounter("gofar.observability.exported",
+		otelmetric.WithDescription("Number of spans and logs exported"), otelmetric.WithUnit("{item}"))
+	if err != nil {
+		return fmt.Errorf("could not create exported metric: %w", err)
+	}
+	dropped, err := meter.Int64ObservableCounter("gofar.observability.dropped",
+		otelmetric.WithDescription("Number of spans and logs dropped because the queue was full or their export failed"), otelmetric.WithUnit("{item}"))
+	if err != nil {
+		return fmt.Errorf("could not create dropped metric: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o otelmetric.Observer) error {
+		for signal, stats := range map[Signal]*exportStats{SignalTraces: t.spanStats, SignalLogs: t.logStats} {
+			if stats == nil {
+				continue
+			}
+			attrs := otelmetric.WithAttributes(attribute.String("signal", string(signal)))
+			o.ObserveInt64(exported, stats.exported.Load(), attrs)
+			o.ObserveInt64(dropped, stats.dropped.Load(), attrs)
+		}
+		return nil
+	}, exported, dropped)
+	return err
+}
@@ -0,0 +1,68 @@
+package observability_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func TestSignalBatch(t *testing.T) {
+	f := newFileTelemetry(t, []observability.Signal{observability.SignalTraces, observability.SignalMetrics},
+		observability.WithSignalBatch(observability.SignalTraces, observability.BatchOptions{ScheduleDelay: 10 * time.Millisecond}),
+		observability.WithRuntimeMetrics(false),
+	)
+
+	_, span := f.TracerProvider.Tracer("test").Start(context.Background(), "batched-span")
+	span.End()
+	assert.Eventually(t, func() bool {
+		return strings.Contains(f.content(observability.SignalTraces), `"Name":"batched-span"`)
+	}, time.Second, 5*time.Millisecond)
+
+	content := f.shutdownContent(observability.SignalMetrics)
+	assert.Contains(t, content, `"Name":"gofar.observability.exported"`)
+	assert.Contains(t, content, `"Name":"gofar.observability.dropped"`)
+}
+
+func TestSignalBatchQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+
+	tel, err := observability.NewTelemetry(context.Background(),
+		observability.WithOTLP(observability.OTLPOptions{
+			Protocol: observability.ProtocolHTTPProtobuf,
+			Endpoint: collector.URL,
+		}),
+		observability.WithSignalBatch(observability.SignalTraces, observability.BatchOptions{QueueSize: 2, BatchSize: 1}),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	require.NoError(t, err)
+
+	// the first span is blocked in its export, the queue takes one more and the others are dropped
+	for range 10 {
+		_, span := tel.TracerProvider.Tracer("test").Start(context.Background(), "span")
+		span.End()
+	}
+	close(release)
+
+	out := captureStderr(t, func() {
+		assert.NoError(t, tel.Shutdown(context.Background()))
+	})
+	var exported, dropped int
+	_, err = fmt.Sscanf(out, "observability: traces flush failed, %d exported, %d dropped", &exported, &dropped)
+	require.NoError(t, err, out)
+	assert.Equal(t, 10, exported+dropped)
+	assert.GreaterOrEqual(t, dropped, 8)
+}
@@ -0,0 +1,40 @@
+package observability
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+)
+
+// Exemplar filters, the same names as in OTEL_METRICS_EXEMPLAR_FILTER.
+const (
+	// ExemplarTraceBased keeps exemplars of the measurements recorded in a sampled span, the default
+	ExemplarTraceBased = "trace_based"
+	ExemplarAlwaysOn   = "always_on"
+	ExemplarAlwaysOff  = "always_off"
+)
+
+// WithExemplarFilter sets which measurements can become exemplars, linking the metrics to the traces they were
+// recorded in. An empty filter falls back to OTEL_METRICS_EXEMPLAR_FILTER, and then to trace_based.
+func WithExemplarFilter(filter string) Customizer {
+	return func(c *Cfg) {
+		c.exemplarFilter = filter
+	}
+}
+
+// exemplarOptions returns the MeterProvider option of the exemplar filter, none to keep the SDK default.
+func (c *Cfg) exemplarOptions() ([]metric.Option, error) {
+	switch c.exemplarFilter {
+	case "":
+		return nil, nil
+	case ExemplarTraceBased:
+		return []metric.Option{metric.WithExemplarFilter(exemplar.TraceBasedFilter)}, nil
+	case ExemplarAlwaysOn:
+		return []metric.Option{metric.WithExemplarFilter(exemplar.AlwaysOnFilter)}, nil
+	case ExemplarAlwaysOff:
+		return []metric.Option{metric.WithExemplarFilter(exemplar.AlwaysOffFilter)}, nil
+	default:
+		return nil, fmt.Errorf("exemplar filter %s not supported", c.exemplarFilter)
+	}
+}
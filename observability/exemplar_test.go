@@ -0,0 +1,33 @@
+package observability_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func recordLatency(t *testing.T, filter string) string {
+	f := newFileTelemetry(t, []observability.Signal{observability.SignalMetrics},
+		observability.WithExemplarFilter(filter),
+		observability.WithRuntimeMetrics(false),
+	)
+
+	// a sampled span, whatever the traces exporter
+	ctx, span := trace.NewTracerProvider().Tracer("test").Start(context.Background(), "request")
+	histogram, err := f.MeterProvider.Meter("test").Float64Histogram("latency")
+	require.NoError(t, err)
+	histogram.Record(ctx, 0.2)
+	span.End()
+
+	return f.shutdownContent(observability.SignalMetrics)
+}
+
+func TestExemplarFilter(t *testing.T) {
+	assert.Contains(t, recordLatency(t, observability.ExemplarTraceBased), `"TraceID"`)
+	assert.NotContains(t, recordLatency(t, observability.ExemplarAlwaysOff), `"TraceID"`)
+}
@@ -0,0 +1,11 @@
+package observability
+
+// Current returns the Telemetry set as the globals, nil when none, for the tests to restore it.
+func Current() *Telemetry {
+	return current
+}
+
+// SetCurrent replaces the Telemetry set as the globals.
+func SetCurrent(t *Telemetry) {
+	current = t
+}
@@ -0,0 +1,419 @@
+package observability
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// Signal is one of the telemetry signals exported by the framework.
+type Signal string
+
+const (
+	SignalTraces  Signal = "traces"
+	SignalMetrics Signal = "metrics"
+	SignalLogs    Signal = "logs"
+)
+
+// OTLP protocols, as in OTEL_EXPORTER_OTLP_PROTOCOL.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// OTLPOptions configure the OTLP exporter of a signal. Empty values are left to the OTEL_EXPORTER_OTLP_* env vars.
+type OTLPOptions struct {
+	// Protocol is grpc or http/protobuf
+	Protocol string
+	// Endpoint is either host:port or a full URL
+	Endpoint string
+	Headers  map[string]string
+	// Insecure disables TLS when true, nil leaving it to the shared options and the env vars
+	Insecure *bool
+	// Compression is none or gzip
+	Compression string
+	// CAFile, CertFile and KeyFile configure TLS, the last two for client authentication
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+	// Timeout bounds each export request
+	Timeout time.Duration
+	// Retry is the backoff of the failed exports, nil keeps the exporter defaults
+	Retry *RetryOptions
+}
+
+// RetryOptions configure the retries of the failed exports.
+type RetryOptions struct {
+	Enabled         bool
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// MaxElapsedTime is the time after which the data is dropped
+	MaxElapsedTime time.Duration
+}
+
+// merge returns the options with the values set in override replacing the ones of o.
+func (o OTLPOptions) merge(override OTLPOptions) OTLPOptions {
+	if override.Protocol != "" {
+		o.Protocol = override.Protocol
+	}
+	if override.Endpoint != "" {
+		o.Endpoint = override.Endpoint
+	}
+	if len(override.Headers) > 0 {
+		headers := make(map[string]string, len(o.Headers)+len(override.Headers))
+		for k, v := range o.Headers {
+			headers[k] = v
+		}
+		for k, v := range override.Headers {
+			headers[k] = v
+		}
+		o.Headers = headers
+	}
+	if override.Insecure != nil {
+		o.Insecure = override.Insecure
+	}
+	if override.Compression != "" {
+		o.Compression = override.Compression
+	}
+	if override.CAFile != "" {
+		o.CAFile = override.CAFile
+	}
+	if override.CertFile != "" {
+		o.CertFile = override.CertFile
+		o.KeyFile = override.KeyFile
+	}
+	if override.InsecureSkipVerify {
+		o.InsecureSkipVerify = true
+	}
+	if override.Timeout > 0 {
+		o.Timeout = override.Timeout
+	}
+	if override.Retry != nil {
+		o.Retry = override.Retry
+	}
+	return o
+}
+
+// protocol resolves the protocol of the signal from the options, then the env vars, defaulting to grpc.
+func (o OTLPOptions) protocol(signal Signal) (string, error) {
+	p := o.Protocol
+	if p == "" {
+		p = os.Getenv("OTEL_EXPORTER_OTLP_" + strings.ToUpper(string(signal)) + "_PROTOCOL")
+	}
+	if p == "" {
+		p = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+
+	switch p {
+	case "", ProtocolGRPC:
+		return ProtocolGRPC, nil
+	case ProtocolHTTPProtobuf, "http":
+		return ProtocolHTTPProtobuf, nil
+	default:
+		return "", fmt.Errorf("otlp protocol %s not supported", p)
+	}
+}
+
+func (o OTLPOptions) validateCompression() error {
+	switch o.Compression {
+	case "", "none", "gzip":
+		return nil
+	default:
+		return fmt.Errorf("otlp compression %s not supported", o.Compression)
+	}
+}
+
+func (o OTLPOptions) tlsConfig() (*tls.Config, error) {
+	if o.CAFile == "" && o.CertFile == "" && !o.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read otlp ca file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in otlp ca file %s", o.CAFile)
+		}
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load otlp client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func isEndpointURL(endpoint string) bool {
+	return strings.Contains(endpoint, "://")
+}
+
+// probe dials the collector of the signal, resolving its address like the exporters do.
+func (o OTLPOptions) probe(ctx context.Context, signal Signal, timeout time.Duration) error {
+	protocol, err := o.protocol(signal)
+	if err != nil {
+		return err
+	}
+
+	endpoint := o.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_" + strings.ToUpper(string(signal)) + "_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if isEndpointURL(endpoint) {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid otlp endpoint %s: %w", endpoint, err)
+		}
+		endpoint = u.Host
+	}
+	if endpoint == "" {
+		endpoint = "localhost"
+	}
+	if _, _, err = net.SplitHostPort(endpoint); err != nil {
+		port := "4317"
+		if protocol == ProtocolHTTPProtobuf {
+			port = "4318"
+		}
+		endpoint = net.JoinHostPort(endpoint, port)
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return fmt.Errorf("otlp collector %s unreachable: %w", endpoint, err)
+	}
+	return conn.Close()
+}
+
+// otlpOptionFuncs are the option constructors of an OTLP exporter package, O being its option type.
+type otlpOptionFuncs[O any] struct {
+	endpointURL func(string) O
+	endpoint    func(string) O
+	headers     func(map[string]string) O
+	insecure    func() O
+	compression func(gzip bool) O
+	timeout     func(time.Duration) O
+	retry       func(RetryOptions) O
+	tls         func(*tls.Config) O
+}
+
+// otlpExporterOptions resolves the protocol of the signal and builds the options of its exporter with the
+// constructors of the protocol.
+func otlpExporterOptions[H, G any](o OTLPOptions, signal Signal, http otlpOptionFuncs[H], grpc otlpOptionFuncs[G]) (
+	string, []H, []G, error,
+) {
+	protocol, err := o.protocol(signal)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if err = o.validateCompression(); err != nil {
+		return "", nil, nil, err
+	}
+	tlsCfg, err := o.tlsConfig()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if protocol == ProtocolHTTPProtobuf {
+		return protocol, buildOTLPOptions(o, tlsCfg, http), nil, nil
+	}
+	return protocol, nil, buildOTLPOptions(o, tlsCfg, grpc), nil
+}
+
+func buildOTLPOptions[O any](o OTLPOptions, tlsCfg *tls.Config, f otlpOptionFuncs[O]) []O {
+	var opts []O
+	if isEndpointURL(o.Endpoint) {
+		opts = append(opts, f.endpointURL(o.Endpoint))
+	} else if o.Endpoint != "" {
+		opts = append(opts, f.endpoint(o.Endpoint))
+	}
+	if len(o.Headers) > 0 {
+		opts = append(opts, f.headers(o.Headers))
+	}
+	if o.Insecure != nil && *o.Insecure {
+		opts = append(opts, f.insecure())
+	}
+	if o.Compression != "" {
+		opts = append(opts, f.compression(o.Compression == "gzip"))
+	}
+	if o.Timeout > 0 {
+		opts = append(opts, f.timeout(o.Timeout))
+	}
+	if o.Retry != nil {
+		opts = append(opts, f.retry(*o.Retry))
+	}
+	if tlsCfg != nil {
+		opts = append(opts, f.tls(tlsCfg))
+	}
+	return opts
+}
+
+func newOTLPTraceExporter(ctx context.Context, o OTLPOptions) (trace.SpanExporter, error) {
+	protocol, httpOpts, grpcOpts, err := otlpExporterOptions(o, SignalTraces,
+		otlpOptionFuncs[otlptracehttp.Option]{
+			endpointURL: otlptracehttp.WithEndpointURL,
+			endpoint:    otlptracehttp.WithEndpoint,
+			headers:     otlptracehttp.WithHeaders,
+			insecure:    otlptracehttp.WithInsecure,
+			compression: func(gzip bool) otlptracehttp.Option {
+				if gzip {
+					return otlptracehttp.WithCompression(otlptracehttp.GzipCompression)
+				}
+				return otlptracehttp.WithCompression(otlptracehttp.NoCompression)
+			},
+			timeout: otlptracehttp.WithTimeout,
+			retry: func(r RetryOptions) otlptracehttp.Option {
+				return otlptracehttp.WithRetry(otlptracehttp.RetryConfig(r))
+			},
+			tls: otlptracehttp.WithTLSClientConfig,
+		},
+		otlpOptionFuncs[otlptracegrpc.Option]{
+			endpointURL: otlptracegrpc.WithEndpointURL,
+			endpoint:    otlptracegrpc.WithEndpoint,
+			headers:     otlptracegrpc.WithHeaders,
+			insecure:    otlptracegrpc.WithInsecure,
+			compression: func(gzip bool) otlptracegrpc.Option {
+				if gzip {
+					return otlptracegrpc.WithCompressor("gzip")
+				}
+				return otlptracegrpc.WithCompressor("")
+			},
+			timeout: otlptracegrpc.WithTimeout,
+			retry: func(r RetryOptions) otlptracegrpc.Option {
+				return otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig(r))
+			},
+			tls: func(c *tls.Config) otlptracegrpc.Option {
+				return otlptracegrpc.WithTLSCredentials(credentials.NewTLS(c))
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if protocol == ProtocolHTTPProtobuf {
+		return otlptracehttp.New(ctx, httpOpts...)
+	}
+	return otlptracegrpc.New(ctx, grpcOpts...)
+}
+
+func newOTLPMetricExporter(ctx context.Context, o OTLPOptions) (metric.Exporter, error) {
+	protocol, httpOpts, grpcOpts, err := otlpExporterOptions(o, SignalMetrics,
+		otlpOptionFuncs[otlpmetrichttp.Option]{
+			endpointURL: otlpmetrichttp.WithEndpointURL,
+			endpoint:    otlpmetrichttp.WithEndpoint,
+			headers:     otlpmetrichttp.WithHeaders,
+			insecure:    otlpmetrichttp.WithInsecure,
+			compression: func(gzip bool) otlpmetrichttp.Option {
+				if gzip {
+					return otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression)
+				}
+				return otlpmetrichttp.WithCompression(otlpmetrichttp.NoCompression)
+			},
+			timeout: otlpmetrichttp.WithTimeout,
+			retry: func(r RetryOptions) otlpmetrichttp.Option {
+				return otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig(r))
+			},
+			tls: otlpmetrichttp.WithTLSClientConfig,
+		},
+		otlpOptionFuncs[otlpmetricgrpc.Option]{
+			endpointURL: otlpmetricgrpc.WithEndpointURL,
+			endpoint:    otlpmetricgrpc.WithEndpoint,
+			headers:     otlpmetricgrpc.WithHeaders,
+			insecure:    otlpmetricgrpc.WithInsecure,
+			compression: func(gzip bool) otlpmetricgrpc.Option {
+				if gzip {
+					return otlpmetricgrpc.WithCompressor("gzip")
+				}
+				return otlpmetricgrpc.WithCompressor("")
+			},
+			timeout: otlpmetricgrpc.WithTimeout,
+			retry: func(r RetryOptions) otlpmetricgrpc.Option {
+				return otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig(r))
+			},
+			tls: func(c *tls.Config) otlpmetricgrpc.Option {
+				return otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(c))
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if protocol == ProtocolHTTPProtobuf {
+		return otlpmetrichttp.New(ctx, httpOpts...)
+	}
+	return otlpmetricgrpc.New(ctx, grpcOpts...)
+}
+
+func newOTLPLogExporter(ctx context.Context, o OTLPOptions) (log.Exporter, error) {
+	protocol, httpOpts, grpcOpts, err := otlpExporterOptions(o, SignalLogs,
+		otlpOptionFuncs[otlploghttp.Option]{
+			endpointURL: otlploghttp.WithEndpointURL,
+			endpoint:    otlploghttp.WithEndpoint,
+			headers:     otlploghttp.WithHeaders,
+			insecure:    otlploghttp.WithInsecure,
+			compression: func(gzip bool) otlploghttp.Option {
+				if gzip {
+					return otlploghttp.WithCompression(otlploghttp.GzipCompression)
+				}
+				return otlploghttp.WithCompression(otlploghttp.NoCompression)
+			},
+			timeout: otlploghttp.WithTimeout,
+			retry: func(r RetryOptions) otlploghttp.Option {
+				return otlploghttp.WithRetry(otlploghttp.RetryConfig(r))
+			},
+			tls: otlploghttp.WithTLSClientConfig,
+		},
+		otlpOptionFuncs[otlploggrpc.Option]{
+			endpointURL: otlploggrpc.WithEndpointURL,
+			endpoint:    otlploggrpc.WithEndpoint,
+			headers:     otlploggrpc.WithHeaders,
+			insecure:    otlploggrpc.WithInsecure,
+			compression: func(gzip bool) otlploggrpc.Option {
+				if gzip {
+					return otlploggrpc.WithCompressor("gzip")
+				}
+				return otlploggrpc.WithCompressor("")
+			},
+			timeout: otlploggrpc.WithTimeout,
+			retry: func(r RetryOptions) otlploggrpc.Option {
+				return otlploggrpc.WithRetry(otlploggrpc.RetryConfig(r))
+			},
+			tls: func(c *tls.Config) otlploggrpc.Option {
+				return otlploggrpc.WithTLSCredentials(credentials.NewTLS(c))
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if protocol == ProtocolHTTPProtobuf {
+		return otlploghttp.New(ctx, httpOpts...)
+	}
+	return otlploggrpc.New(ctx, grpcOpts...)
+}
@@ -0,0 +1,80 @@
+package observability_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+var insecure = true
+
+func TestStartObservabilityProtocols(t *testing.T) {
+	restoreGlobals(t)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+
+	err := observability.StartObservability(context.Background(),
+		observability.WithServiceName("test"),
+		observability.WithOTLP(observability.OTLPOptions{Endpoint: "http://localhost:4318", Compression: "gzip"}),
+		observability.WithSignalOTLP(observability.SignalTraces, observability.OTLPOptions{Protocol: observability.ProtocolGRPC, Endpoint: "localhost:4317", Insecure: &insecure}),
+	)
+	require.NoError(t, err)
+	// no collector is listening, so only the flush of the metrics fails
+	err = observability.StopObservability(context.Background())
+	assert.ErrorContains(t, err, "failed to upload metrics")
+}
+
+func TestStartObservabilityUnsupportedProtocol(t *testing.T) {
+	restoreGlobals(t)
+
+	err := observability.StartObservability(context.Background(),
+		observability.WithSignalOTLP(observability.SignalMetrics, observability.OTLPOptions{Protocol: "http/json"}),
+	)
+	assert.ErrorContains(t, err, "otlp protocol http/json not supported")
+}
+
+func TestNewTelemetryUnsupportedCompression(t *testing.T) {
+	_, err := observability.NewTelemetry(context.Background(),
+		observability.WithSignalOTLP(observability.SignalLogs, observability.OTLPOptions{Compression: "zstd"}),
+	)
+	assert.ErrorContains(t, err, "otlp compression zstd not supported")
+}
+
+func TestTelemetrySignalSecureOverride(t *testing.T) {
+	var requests atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer collector.Close()
+
+	secure := false
+	tel, err := observability.NewTelemetry(context.Background(),
+		observability.WithOTLP(observability.OTLPOptions{
+			Protocol: observability.ProtocolHTTPProtobuf,
+			Endpoint: collector.Listener.Addr().String(),
+			Insecure: &insecure,
+			Retry:    &observability.RetryOptions{Enabled: false},
+		}),
+		observability.WithSignalOTLP(observability.SignalTraces, observability.OTLPOptions{Insecure: &secure}),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	require.NoError(t, err)
+
+	_, span := tel.TracerProvider.Tracer("test").Start(context.Background(), "span")
+	span.End()
+
+	// the traces are sent over TLS to the plain text collector, the shared insecure option being turned off
+	captureStderr(t, func() {
+		err = tel.Shutdown(context.Background())
+	})
+	assert.ErrorContains(t, err, "HTTPS")
+	assert.Zero(t, requests.Load())
+}
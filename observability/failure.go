@@ -0,0 +1,125 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// FailurePolicy is what StartObservability does when the exporter of a signal cannot be created.
+type FailurePolicy string
+
+const (
+	// FailureFail returns the exporter error, the default
+	FailureFail FailurePolicy = "fail"
+	// FailureDegrade replaces the exporter by the fallback one and logs a warning
+	FailureDegrade FailurePolicy = "degrade"
+)
+
+// WithFailurePolicy sets the policy applied when an exporter cannot be created. The degraded signals use the
+// fallback exporter, ExporterNone when empty.
+func WithFailurePolicy(policy FailurePolicy, fallback ExporterKind) Customizer {
+	return func(c *Cfg) {
+		c.failurePolicy = policy
+		c.failureFallback = fallback
+	}
+}
+
+// WithStartupCheck dials the OTLP collector of each signal before creating its exporter, failing it when the
+// collector is unreachable within the timeout. The OTLP exporters connect lazily, so without the check an
+// unreachable collector only shows up as export errors.
+func WithStartupCheck(timeout time.Duration) Customizer {
+	return func(c *Cfg) {
+		c.startupCheck = timeout
+	}
+}
+
+// Degraded returns the signals of the global Telemetry running on the fallback exporter, see Telemetry.Degraded.
+func Degraded() map[Signal]error {
+	if current == nil {
+		return nil
+	}
+	return current.Degraded()
+}
+
+// validateFailurePolicy fails on the unknown policies, which would otherwise fail silently.
+func (c *Cfg) validateFailurePolicy() error {
+	switch c.failurePolicy {
+	case "", FailureFail, FailureDegrade:
+		return nil
+	default:
+		return fmt.Errorf("failure policy %s not supported", c.failurePolicy)
+	}
+}
+
+// checkCollector dials the collector of the signal when the startup check is enabled.
+func (c *Cfg) checkCollector(ctx context.Context, signal Signal, o OTLPOptions) error {
+	if c.startupCheck <= 0 {
+		return nil
+	}
+	return o.probe(ctx, signal, c.startupCheck)
+}
+
+// degrade switches the signal to the fallback exporter after the error, returning false when the policy is to
+// fail or the fallback already failed.
+func (c *Cfg) degrade(signal Signal, err error) bool {
+	if c.failurePolicy != FailureDegrade {
+		return false
+	}
+	if _, ok := c.degraded[signal]; ok {
+		return false
+	}
+
+	fallback := c.failureFallback
+	if fallback == "" {
+		fallback = ExporterNone
+	}
+	if c.signalExporter == nil {
+		c.signalExporter = make(map[Signal]ExporterKind)
+	}
+	c.signalExporter[signal] = fallback
+
+	if c.degraded == nil {
+		c.degraded = make(map[Signal]error)
+	}
+	c.degraded[signal] = err
+	return true
+}
+
+// reportDegraded warns of each degraded signal on stderr, and in the logs unless they are degraded too, and publishes
+// the gofar.observability.degraded gauge.
+func (t *Telemetry) reportDegraded() error {
+	_, logsDegraded := t.degraded[SignalLogs]
+	for signal, err := range t.degraded {
+		fmt.Fprintf(os.Stderr, "observability: %s degraded, the exporter could not be created: %v\n", signal, err)
+		if !logsDegraded {
+			t.Logger("observability").Warn("observability degraded, the exporter could not be created",
+				"signal", signal, "error", err)
+		}
+	}
+
+	meter := t.MeterProvider.Meter("github.com/davfer/goforarun/observability")
+	gauge, err := meter.Int64ObservableGauge(
+		"gofar.observability.degraded",
+		otelmetric.WithDescription("Whether the signal runs on the fallback exporter"),
+	)
+	if err != nil {
+		return fmt.Errorf("could not create degraded gauge: %w", err)
+	}
+	_, err = meter.RegisterCallback(
+		func(_ context.Context, o otelmetric.Observer) error {
+			for _, signal := range []Signal{SignalTraces, SignalMetrics, SignalLogs} {
+				var v int64
+				if _, ok := t.degraded[signal]; ok {
+					v = 1
+				}
+				o.ObserveInt64(gauge, v, otelmetric.WithAttributes(attribute.String("signal", string(signal))))
+			}
+			return nil
+		}, gauge)
+	return err
+}
@@ -0,0 +1,92 @@
+package observability_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+// unreachableEndpoint returns the address of a closed listener.
+func unreachableEndpoint(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+	return l.Addr().String()
+}
+
+func TestStartObservabilityUnreachableCollector(t *testing.T) {
+	restoreGlobals(t)
+
+	opts := []observability.Customizer{
+		observability.WithOTLP(observability.OTLPOptions{Endpoint: unreachableEndpoint(t), Insecure: &insecure}),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+		observability.WithStartupCheck(100 * time.Millisecond),
+	}
+
+	err := observability.StartObservability(context.Background(), opts...)
+	assert.ErrorContains(t, err, "unreachable")
+
+	err = observability.StartObservability(context.Background(),
+		append(opts, observability.WithFailurePolicy(observability.FailureDegrade, ""))...)
+	require.NoError(t, err)
+	_ = observability.StopObservability(context.Background())
+
+	degraded := observability.Degraded()
+	assert.Len(t, degraded, 1)
+	assert.ErrorContains(t, degraded[observability.SignalTraces], "unreachable")
+}
+
+func TestStartObservabilityReachableCollector(t *testing.T) {
+	restoreGlobals(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	err = observability.StartObservability(context.Background(),
+		observability.WithOTLP(observability.OTLPOptions{Endpoint: l.Addr().String(), Insecure: &insecure}),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+		observability.WithStartupCheck(time.Second),
+		observability.WithFailurePolicy(observability.FailureDegrade, observability.ExporterStdout),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = observability.StopObservability(ctx)
+
+	assert.Empty(t, observability.Degraded())
+}
+
+func TestNewTelemetryDegradedWarning(t *testing.T) {
+	var tel *observability.Telemetry
+	out := captureStderr(t, func() {
+		var err error
+		tel, err = observability.NewTelemetry(context.Background(),
+			observability.WithOTLP(observability.OTLPOptions{Endpoint: unreachableEndpoint(t), Insecure: &insecure}),
+			observability.WithSignalExporter(observability.SignalTraces, observability.ExporterNone),
+			observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+			observability.WithStartupCheck(100*time.Millisecond),
+			observability.WithFailurePolicy(observability.FailureDegrade, ""),
+		)
+		require.NoError(t, err)
+	})
+	defer tel.Shutdown(context.Background())
+
+	assert.Contains(t, out, "observability: logs degraded, the exporter could not be created")
+	assert.Contains(t, out, "unreachable")
+}
+
+func TestNewTelemetryUnknownFailurePolicy(t *testing.T) {
+	_, err := observability.NewTelemetry(context.Background(),
+		observability.WithFailurePolicy("ignore", ""),
+	)
+	assert.ErrorContains(t, err, "failure policy ignore not supported")
+}
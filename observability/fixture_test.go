@@ -0,0 +1,79 @@
+package observability_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/log/global"
+
+	"github.com/davfer/goforarun/logger"
+	"github.com/davfer/goforarun/observability"
+)
+
+// fileTelemetry is a Telemetry, without the globals, exporting some signals to the files of a temp dir and the
+// others nowhere.
+type fileTelemetry struct {
+	*observability.Telemetry
+	t     *testing.T
+	paths map[observability.Signal]string
+}
+
+func newFileTelemetry(t *testing.T, signals []observability.Signal, opts ...observability.Customizer) *fileTelemetry {
+	f := &fileTelemetry{t: t, paths: make(map[observability.Signal]string, len(signals))}
+	dir := t.TempDir()
+
+	var fileOpts []observability.Customizer
+	for _, signal := range []observability.Signal{observability.SignalTraces, observability.SignalMetrics, observability.SignalLogs} {
+		fileOpts = append(fileOpts, observability.WithSignalExporter(signal, observability.ExporterNone))
+	}
+	for _, signal := range signals {
+		f.paths[signal] = filepath.Join(dir, string(signal)+".jsonl")
+		fileOpts = append(fileOpts,
+			observability.WithSignalExporter(signal, observability.ExporterFile),
+			observability.WithSignalFile(signal, f.paths[signal]),
+		)
+	}
+
+	tel, err := observability.NewTelemetry(context.Background(), append(fileOpts, opts...)...)
+	require.NoError(t, err)
+	f.Telemetry = tel
+	return f
+}
+
+// content returns what was exported of the signal so far.
+func (f *fileTelemetry) content(signal observability.Signal) string {
+	content, err := os.ReadFile(f.paths[signal])
+	require.NoError(f.t, err)
+	return string(content)
+}
+
+// shutdownContent shuts the Telemetry down and returns what was exported of the signal.
+func (f *fileTelemetry) shutdownContent(signal observability.Signal) string {
+	_ = f.Shutdown(context.Background())
+	return f.content(signal)
+}
+
+// restoreGlobals restores the default logger, the otel globals and the Telemetry set as the globals after the test,
+// for the tests calling StartObservability or SetGlobal.
+func restoreGlobals(t *testing.T) {
+	telemetry := observability.Current()
+	defaultLogger := slog.Default()
+	tracerProvider := otel.GetTracerProvider()
+	meterProvider := otel.GetMeterProvider()
+	loggerProvider := global.GetLoggerProvider()
+	propagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetMeterProvider(meterProvider)
+		global.SetLoggerProvider(loggerProvider)
+		otel.SetTextMapPropagator(propagator)
+		logger.SetFatalHook(nil)
+		observability.SetCurrent(telemetry)
+	})
+}
@@ -0,0 +1,140 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exportStats count the items sent by an exporter, the failed ones being dropped, and the items pending in the batch
+// processor.
+type exportStats struct {
+	exported atomic.Int64
+	dropped  atomic.Int64
+	pending  atomic.Int64
+}
+
+func (s *exportStats) record(n int, err error) {
+	s.pending.Add(-int64(n))
+	if err != nil {
+		s.dropped.Add(int64(n))
+	} else {
+		s.exported.Add(int64(n))
+	}
+}
+
+// countingSpanExporter counts the spans of the wrapped exporter.
+type countingSpanExporter struct {
+	trace.SpanExporter
+	stats *exportStats
+}
+
+func (e *countingSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.stats.record(len(spans), err)
+	return err
+}
+
+// countingLogExporter counts the records of the wrapped exporter.
+type countingLogExporter struct {
+	log.Exporter
+	stats *exportStats
+}
+
+func (e *countingLogExporter) Export(ctx context.Context, records []log.Record) error {
+	err := e.Exporter.Export(ctx, records)
+	e.stats.record(len(records), err)
+	return err
+}
+
+// provider is the flush and shutdown of a signal provider.
+type provider struct {
+	signal   Signal
+	flush    func(context.Context) error
+	shutdown func(context.Context) error
+	stats    *exportStats
+}
+
+// providers returns the SDK providers of the Telemetry, the logs last so the others can log until they stop.
+func (t *Telemetry) providers() (p []provider) {
+	if t.tracerProvider != nil {
+		p = append(p, provider{SignalTraces, t.tracerProvider.ForceFlush, t.tracerProvider.Shutdown, t.spanStats})
+	}
+	if t.meterProvider != nil {
+		p = append(p, provider{SignalMetrics, t.meterProvider.ForceFlush, t.meterProvider.Shutdown, nil})
+	}
+	if t.loggerProvider != nil {
+		p = append(p, provider{SignalLogs, t.loggerProvider.ForceFlush, t.loggerProvider.Shutdown, t.logStats})
+	}
+	return
+}
+
+// ForceFlush exports the buffered spans, metrics and logs, giving each provider its slice of the context deadline.
+func (t *Telemetry) ForceFlush(ctx context.Context) (err error) {
+	providers := t.providers()
+	for i, p := range providers {
+		pctx, cancel := deadlineSlice(ctx, len(providers)-i)
+		err = errors.Join(err, p.flush(pctx))
+		cancel()
+	}
+	return
+}
+
+// Shutdown reports the log records suppressed by sampling, flushes and stops the providers, giving each its slice of
+// the context deadline, and closes the export files. The outcome of each provider is written to stderr, as the log
+// pipeline is being stopped.
+func (t *Telemetry) Shutdown(ctx context.Context) (err error) {
+	if t.sampling != nil {
+		t.sampling.Close(ctx)
+	}
+
+	providers := t.providers()
+	for i, p := range providers {
+		pctx, cancel := deadlineSlice(ctx, len(providers)-i)
+		perr := errors.Join(p.flush(pctx), p.shutdown(pctx))
+		cancel()
+
+		reportFlush(p, perr)
+		err = errors.Join(err, perr)
+	}
+	for _, f := range t.exportFiles {
+		err = errors.Join(err, f.Close())
+	}
+	t.exportFiles = nil
+
+	return
+}
+
+// deadlineSlice returns a context with an even share of the remaining time of ctx among parts, the time not used by
+// a part being left to the next ones.
+func deadlineSlice(ctx context.Context, parts int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || parts <= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(parts))
+}
+
+// reportFlush writes the outcome of the flush of the provider to stderr, a failure when it failed or when exports
+// failed before it and dropped data.
+func reportFlush(p provider, err error) {
+	var counts string
+	if p.stats != nil {
+		dropped := p.stats.dropped.Load()
+		counts = fmt.Sprintf(", %d exported, %d dropped", p.stats.exported.Load(), dropped)
+		if err == nil && dropped > 0 {
+			err = errors.New("previous exports failed")
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "observability: %s flush failed%s: %v\n", p.signal, counts, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "observability: %s flushed%s\n", p.signal, counts)
+}
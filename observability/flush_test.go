@@ -0,0 +1,58 @@
+package observability_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func captureStderr(t *testing.T, f func()) string {
+	return captureOutput(t, &os.Stderr, f)
+}
+
+func TestTelemetryShutdownReport(t *testing.T) {
+	tel := newFileTelemetry(t, []observability.Signal{observability.SignalTraces})
+
+	for range 3 {
+		_, span := tel.TracerProvider.Tracer("test").Start(context.Background(), "span")
+		span.End()
+	}
+	require.NoError(t, tel.ForceFlush(context.Background()))
+
+	out := captureStderr(t, func() {
+		assert.NoError(t, tel.Shutdown(context.Background()))
+	})
+	assert.Equal(t, "observability: traces flushed, 3 exported, 0 dropped\n", out)
+}
+
+func TestTelemetryShutdownJoinsErrors(t *testing.T) {
+	endpoint := unreachableEndpoint(t)
+	tel, err := observability.NewTelemetry(context.Background(),
+		observability.WithOTLP(observability.OTLPOptions{Endpoint: endpoint, Insecure: &insecure, Protocol: observability.ProtocolHTTPProtobuf}),
+		observability.WithRuntimeMetrics(false),
+	)
+	require.NoError(t, err)
+
+	_, span := tel.TracerProvider.Tracer("test").Start(context.Background(), "span")
+	span.End()
+	tel.Logger("test").Info("log")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	out := captureStderr(t, func() {
+		err = tel.Shutdown(ctx)
+	})
+
+	// the errors of every provider are kept, the log processor only reports its failures to the otel handler
+	assert.ErrorContains(t, err, "traces export")
+	assert.ErrorContains(t, err, "failed to upload metrics")
+	assert.Contains(t, out, "observability: traces flush failed, 0 exported, 1 dropped")
+	assert.Contains(t, out, "observability: metrics flush failed")
+	assert.Contains(t, out, "observability: logs flush failed, 0 exported, 1 dropped: previous exports failed")
+}
@@ -0,0 +1,206 @@
+// Package observabilitytest records the spans, metrics and logs of a test in memory and asserts on them.
+package observabilitytest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/thejerf/slogassert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/davfer/goforarun/logger"
+)
+
+// Kit holds the in-memory providers of a test. The spans are exported synchronously when they end, the metrics are
+// collected on every assertion and the logs are kept by a slogassert handler.
+type Kit struct {
+	t              testing.TB
+	spans          *tracetest.InMemoryExporter
+	reader         *metric.ManualReader
+	TracerProvider *trace.TracerProvider
+	MeterProvider  *metric.MeterProvider
+	Logs           *slogassert.Handler
+}
+
+// New creates a Kit for the test, its providers are shut down when the test ends. The globals are not touched, so
+// the code under test needs to receive the providers and the Logger; use Install otherwise.
+func New(t testing.TB) *Kit {
+	t.Helper()
+
+	k := &Kit{
+		t:      t,
+		spans:  tracetest.NewInMemoryExporter(),
+		reader: metric.NewManualReader(),
+		Logs:   slogassert.New(t, logger.LevelTrace, nil),
+	}
+	k.TracerProvider = trace.NewTracerProvider(trace.WithSyncer(k.spans))
+	k.MeterProvider = metric.NewMeterProvider(metric.WithReader(k.reader))
+
+	t.Cleanup(func() {
+		_ = k.TracerProvider.Shutdown(context.Background())
+		_ = k.MeterProvider.Shutdown(context.Background())
+	})
+	return k
+}
+
+// Install creates a Kit and sets its providers and logger as the globals, restoring the previous ones when the test
+// ends. Tests using it cannot run in parallel.
+func Install(t testing.TB) *Kit {
+	t.Helper()
+
+	tracerProvider := otel.GetTracerProvider()
+	meterProvider := otel.GetMeterProvider()
+	defaultLogger := slog.Default()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetMeterProvider(meterProvider)
+		slog.SetDefault(defaultLogger)
+	})
+
+	k := New(t)
+	otel.SetTracerProvider(k.TracerProvider)
+	otel.SetMeterProvider(k.MeterProvider)
+	slog.SetDefault(slog.New(k.Logs))
+	return k
+}
+
+// Logger returns a logger of the channel writing to the Kit, like logger.Get does with the default one.
+func (k *Kit) Logger(channel string) *slog.Logger {
+	return slog.New(k.Logs).With(slog.String("channel", channel))
+}
+
+// Reset forgets the recorded spans and logs. The metrics are cumulative and keep their values.
+func (k *Kit) Reset() {
+	k.spans.Reset()
+	k.Logs.Reset()
+}
+
+// Spans returns the ended spans, in the order they ended.
+func (k *Kit) Spans() tracetest.SpanStubs {
+	return k.spans.GetSpans()
+}
+
+// AssertSpan fails the test unless a span with the name and, at least, the attributes ended. It returns the first
+// matching span.
+func (k *Kit) AssertSpan(name string, attrs ...attribute.KeyValue) tracetest.SpanStub {
+	k.t.Helper()
+
+	var names []string
+	for _, span := range k.spans.GetSpans() {
+		if span.Name == name && hasAttributes(attribute.NewSet(span.Attributes...), attrs) {
+			return span
+		}
+		names = append(names, span.Name)
+	}
+	k.t.Errorf("no span %s with attributes %v, got spans [%s]", name, attrs, strings.Join(names, ", "))
+	return tracetest.SpanStub{}
+}
+
+// AssertNoSpan fails the test if a span with the name ended.
+func (k *Kit) AssertNoSpan(name string) {
+	k.t.Helper()
+
+	for _, span := range k.spans.GetSpans() {
+		if span.Name == name {
+			k.t.Errorf("unexpected span %s", name)
+			return
+		}
+	}
+}
+
+// AssertCounter fails the test unless the sum of the data points of the counter, or up down counter, having at
+// least the attributes equals want.
+func (k *Kit) AssertCounter(name string, want float64, attrs ...attribute.KeyValue) {
+	k.t.Helper()
+
+	got, err := k.Value(name, attrs...)
+	if err != nil {
+		k.t.Error(err)
+		return
+	}
+	if got != want {
+		k.t.Errorf("counter %s with attributes %v is %g, want %g", name, attrs, got, want)
+	}
+}
+
+// Value collects the metrics and returns the sum of the data points of the counter or gauge having at least the
+// attributes. Histograms are summed by their count.
+func (k *Kit) Value(name string, attrs ...attribute.KeyValue) (float64, error) {
+	var rm metricdata.ResourceMetrics
+	if err := k.reader.Collect(context.Background(), &rm); err != nil {
+		return 0, fmt.Errorf("could not collect metrics: %w", err)
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				return sumPoints(data.DataPoints, attrs), nil
+			case metricdata.Sum[float64]:
+				return sumPoints(data.DataPoints, attrs), nil
+			case metricdata.Gauge[int64]:
+				return sumPoints(data.DataPoints, attrs), nil
+			case metricdata.Gauge[float64]:
+				return sumPoints(data.DataPoints, attrs), nil
+			case metricdata.Histogram[int64]:
+				return countPoints(data.DataPoints, attrs), nil
+			case metricdata.Histogram[float64]:
+				return countPoints(data.DataPoints, attrs), nil
+			default:
+				return 0, fmt.Errorf("metric %s of type %T not supported", name, m.Data)
+			}
+		}
+	}
+	return 0, fmt.Errorf("no metric %s recorded", name)
+}
+
+// AssertLog fails the test unless a log with the message, at the level and of the channel, was emitted. The
+// matching logs are marked as asserted.
+func (k *Kit) AssertLog(channel string, level slog.Level, msg string) {
+	k.t.Helper()
+
+	k.Logs.AssertSomePrecise(slogassert.LogMessageMatch{
+		Message: msg,
+		Level:   level,
+		Attrs:   map[string]any{"channel": channel},
+	})
+}
+
+func sumPoints[N int64 | float64](points []metricdata.DataPoint[N], attrs []attribute.KeyValue) (sum float64) {
+	for _, p := range points {
+		if hasAttributes(p.Attributes, attrs) {
+			sum += float64(p.Value)
+		}
+	}
+	return
+}
+
+func countPoints[N int64 | float64](points []metricdata.HistogramDataPoint[N], attrs []attribute.KeyValue) (count float64) {
+	for _, p := range points {
+		if hasAttributes(p.Attributes, attrs) {
+			count += float64(p.Count)
+		}
+	}
+	return
+}
+
+func hasAttributes(set attribute.Set, attrs []attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if v, ok := set.Value(a.Key); !ok || v != a.Value {
+			return false
+		}
+	}
+	return true
+}
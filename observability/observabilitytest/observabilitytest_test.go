@@ -0,0 +1,58 @@
+package observabilitytest_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/davfer/goforarun/logger"
+	"github.com/davfer/goforarun/observability/observabilitytest"
+)
+
+func TestKit(t *testing.T) {
+	kit := observabilitytest.New(t)
+
+	_, span := kit.TracerProvider.Tracer("test").Start(context.Background(), "checkout")
+	span.SetAttributes(attribute.String("order.id", "42"))
+	span.End()
+
+	counter, err := kit.MeterProvider.Meter("test").Int64Counter("orders")
+	require.NoError(t, err)
+	counter.Add(context.Background(), 2, metric.WithAttributes(attribute.String("status", "paid")))
+	counter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("status", "failed")))
+
+	kit.Logger("orders").Warn("order slow")
+
+	kit.AssertSpan("checkout", attribute.String("order.id", "42"))
+	kit.AssertNoSpan("refund")
+	kit.AssertCounter("orders", 3)
+	kit.AssertCounter("orders", 2, attribute.String("status", "paid"))
+	kit.AssertLog("orders", slog.LevelWarn, "order slow")
+
+	kit.Reset()
+	assert.Empty(t, kit.Spans())
+	kit.Logs.AssertEmpty()
+}
+
+func TestInstall(t *testing.T) {
+	t.Run("installed", func(t *testing.T) {
+		kit := observabilitytest.Install(t)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "global")
+		span.End()
+		logger.Get("global").Info("global log")
+
+		kit.AssertSpan("global")
+		kit.AssertLog("global", slog.LevelInfo, "global log")
+	})
+
+	_, span := otel.Tracer("test").Start(context.Background(), "after")
+	assert.False(t, span.IsRecording())
+	span.End()
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package observability
+
+import (
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// processMetrics are not reported outside unix systems.
+type processMetrics struct{}
+
+func (p *processMetrics) create(otelmetric.Meter) error {
+	return nil
+}
+
+func (p *processMetrics) instruments() []otelmetric.Observable {
+	return nil
+}
+
+func (p *processMetrics) observe(otelmetric.Observer) {}
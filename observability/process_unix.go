@@ -0,0 +1,65 @@
+//go:build unix
+
+package observability
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+var (
+	cpuModeUser   = otelmetric.WithAttributes(attribute.String("cpu.mode", "user"))
+	cpuModeSystem = otelmetric.WithAttributes(attribute.String("cpu.mode", "system"))
+)
+
+// processMetrics observe the CPU, memory and file descriptors of the process. The memory and file descriptors are
+// read from /proc, so they are only reported on Linux.
+type processMetrics struct {
+	cpuTime     otelmetric.Float64ObservableCounter
+	memoryUsage otelmetric.Int64ObservableUpDownCounter
+	openFiles   otelmetric.Int64ObservableUpDownCounter
+}
+
+func (p *processMetrics) create(meter otelmetric.Meter) (err error) {
+	if p.cpuTime, err = meter.Float64ObservableCounter("process.cpu.time",
+		otelmetric.WithDescription("Total CPU seconds broken down by different CPU modes"), otelmetric.WithUnit("s")); err != nil {
+		return
+	}
+	if p.memoryUsage, err = meter.Int64ObservableUpDownCounter("process.memory.usage",
+		otelmetric.WithDescription("The amount of physical memory in use"), otelmetric.WithUnit("By")); err != nil {
+		return
+	}
+	p.openFiles, err = meter.Int64ObservableUpDownCounter("process.open_file_descriptor.count",
+		otelmetric.WithDescription("Number of file descriptors in use by the process"), otelmetric.WithUnit("{file_descriptor}"))
+	return
+}
+
+func (p *processMetrics) instruments() []otelmetric.Observable {
+	return []otelmetric.Observable{p.cpuTime, p.memoryUsage, p.openFiles}
+}
+
+func (p *processMetrics) observe(o otelmetric.Observer) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err == nil {
+		o.ObserveFloat64(p.cpuTime, time.Duration(usage.Utime.Nano()).Seconds(), cpuModeUser)
+		o.ObserveFloat64(p.cpuTime, time.Duration(usage.Stime.Nano()).Seconds(), cpuModeSystem)
+	}
+
+	if statm, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(statm)); len(fields) > 1 {
+			if pages, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				o.ObserveInt64(p.memoryUsage, pages*int64(os.Getpagesize()))
+			}
+		}
+	}
+
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		o.ObserveInt64(p.openFiles, int64(len(fds)))
+	}
+}
@@ -0,0 +1,41 @@
+package observability
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// WithPrometheus attaches a Prometheus reader to the MeterProvider, alongside the metrics exporter. The metrics are
+// pulled from PrometheusHandler instead of pushed.
+func WithPrometheus(enabled bool) Customizer {
+	return func(c *Cfg) {
+		c.prometheus = enabled
+	}
+}
+
+// PrometheusHandler serves the metrics of the global Telemetry, see Telemetry.PrometheusHandler.
+func PrometheusHandler() http.Handler {
+	if current == nil {
+		return nil
+	}
+	return current.PrometheusHandler()
+}
+
+// newPrometheusReader creates the Prometheus reader on a new registry, nil when it is disabled.
+func (c *Cfg) newPrometheusReader() (metric.Reader, error) {
+	if !c.prometheus {
+		return nil, nil
+	}
+
+	registry := prometheus.NewRegistry()
+	reader, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, fmt.Errorf("could not create prometheus reader: %w", err)
+	}
+	c.prometheusRegistry = registry
+	return reader, nil
+}
@@ -0,0 +1,52 @@
+package observability_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	restoreGlobals(t)
+
+	err := observability.StartObservability(context.Background(),
+		observability.WithPrometheus(true),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalTraces, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	require.NoError(t, err)
+	defer func() { _ = observability.StopObservability(context.Background()) }()
+
+	counter, err := otel.Meter("test").Int64Counter("test.requests")
+	require.NoError(t, err)
+	counter.Add(context.Background(), 3)
+
+	rec := httptest.NewRecorder()
+	observability.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "test_requests_total")
+	assert.Contains(t, rec.Body.String(), "go_goroutine_count")
+}
+
+func TestPrometheusHandlerDisabled(t *testing.T) {
+	restoreGlobals(t)
+
+	err := observability.StartObservability(context.Background(),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalTraces, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	require.NoError(t, err)
+	_ = observability.StopObservability(context.Background())
+
+	assert.Nil(t, observability.PrometheusHandler())
+}
@@ -0,0 +1,54 @@
+package observability
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagator names, the same as in OTEL_PROPAGATORS.
+const (
+	PropagatorTraceContext = "tracecontext"
+	PropagatorBaggage      = "baggage"
+	PropagatorB3           = "b3"
+	PropagatorB3Multi      = "b3multi"
+	PropagatorJaeger       = "jaeger"
+	PropagatorNone         = "none"
+)
+
+// newPropagator composes the named propagators. Without names it falls back to the OTEL_PROPAGATORS env var, and
+// then to tracecontext and baggage.
+func newPropagator(names []string) (propagation.TextMapPropagator, error) {
+	if len(names) == 0 {
+		if env := os.Getenv("OTEL_PROPAGATORS"); env != "" {
+			names = strings.Split(env, ",")
+		}
+	}
+	if len(names) == 0 {
+		names = []string{PropagatorTraceContext, PropagatorBaggage}
+	}
+
+	var propagators []propagation.TextMapPropagator
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case PropagatorTraceContext:
+			propagators = append(propagators, propagation.TraceContext{})
+		case PropagatorBaggage:
+			propagators = append(propagators, propagation.Baggage{})
+		case PropagatorB3:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case PropagatorB3Multi:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case PropagatorJaeger:
+			propagators = append(propagators, jaeger.Jaeger{})
+		case PropagatorNone:
+		default:
+			return nil, fmt.Errorf("propagator %s not supported", name)
+		}
+	}
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
@@ -0,0 +1,54 @@
+package observability_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func TestStartObservabilityPropagators(t *testing.T) {
+	restoreGlobals(t)
+
+	err := observability.StartObservability(context.Background(),
+		observability.WithPropagators(observability.PropagatorB3Multi, observability.PropagatorBaggage, observability.PropagatorJaeger),
+		observability.WithSignalExporter(observability.SignalTraces, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	require.NoError(t, err)
+
+	fields := otel.GetTextMapPropagator().Fields()
+	assert.Contains(t, fields, "x-b3-traceid")
+	assert.Contains(t, fields, "baggage")
+	assert.Contains(t, fields, "uber-trace-id")
+	assert.NotContains(t, fields, "traceparent")
+}
+
+func TestStartObservabilityPropagatorsFromEnv(t *testing.T) {
+	restoreGlobals(t)
+
+	t.Setenv("OTEL_PROPAGATORS", "tracecontext,b3")
+	err := observability.StartObservability(context.Background(),
+		observability.WithSignalExporter(observability.SignalTraces, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	require.NoError(t, err)
+
+	fields := otel.GetTextMapPropagator().Fields()
+	assert.Contains(t, fields, "traceparent")
+	assert.Contains(t, fields, "b3")
+
+	t.Setenv("OTEL_PROPAGATORS", "xray")
+	err = observability.StartObservability(context.Background(),
+		observability.WithSignalExporter(observability.SignalTraces, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	assert.ErrorContains(t, err, "propagator xray not supported")
+}
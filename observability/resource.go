@@ -0,0 +1,108 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// k8sEnvVars are the downward API env vars read by the Kubernetes detector, the K8S_ ones first.
+var k8sEnvVars = []struct {
+	key   attribute.Key
+	names []string
+}{
+	{semconv.K8SPodNameKey, []string{"K8S_POD_NAME", "POD_NAME"}},
+	{semconv.K8SPodUIDKey, []string{"K8S_POD_UID", "POD_UID"}},
+	{semconv.K8SNamespaceNameKey, []string{"K8S_NAMESPACE_NAME", "POD_NAMESPACE"}},
+	{semconv.K8SNodeNameKey, []string{"K8S_NODE_NAME", "NODE_NAME"}},
+}
+
+// WithEnvironment sets the deployment.environment.name resource attribute, e.g. production or staging.
+func WithEnvironment(environment string) Customizer {
+	return func(c *Cfg) {
+		c.environment = environment
+	}
+}
+
+// WithResourceAttributes adds custom attributes to the resource, overriding the detected ones.
+func WithResourceAttributes(attrs map[string]string) Customizer {
+	return func(c *Cfg) {
+		c.resourceAttrs = attrs
+	}
+}
+
+// WithBuildInfo adds the commit and the date of the build to the resource.
+func WithBuildInfo(commit, date string) Customizer {
+	return func(c *Cfg) {
+		c.buildCommit = commit
+		c.buildDate = date
+	}
+}
+
+// newResource describes the service, its process, container and Kubernetes pod. The configured attributes override
+// the detected ones, and OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME override both.
+func (c *Cfg) newResource(ctx context.Context) (*resource.Resource, error) {
+	var attrs []attribute.KeyValue
+	if c.serviceName != "" {
+		attrs = append(attrs, semconv.ServiceNameKey.String(c.serviceName))
+	}
+	if c.serviceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(c.serviceVersion))
+	}
+	if c.buildCommit != "" {
+		attrs = append(attrs, semconv.VCSRefHeadRevision(c.buildCommit))
+	}
+	if c.buildDate != "" {
+		attrs = append(attrs, attribute.String("service.build.date", c.buildDate))
+	}
+	if c.environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentName(c.environment))
+	}
+	for k, v := range c.resourceAttrs {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithProcessPID(),
+		resource.WithProcessExecutableName(),
+		resource.WithProcessExecutablePath(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithProcessRuntimeDescription(),
+		resource.WithContainerID(),
+		resource.WithDetectors(k8sDetector{}),
+		resource.WithAttributes(attrs...),
+		resource.WithFromEnv(),
+	)
+	// a detector failing, e.g. without access to the cgroups, leaves its attributes out
+	if errors.Is(err, resource.ErrPartialResource) {
+		return res, nil
+	}
+	return res, err
+}
+
+// k8sDetector reads the pod, namespace and node from the env vars set by the Kubernetes downward API.
+type k8sDetector struct{}
+
+func (k8sDetector) Detect(context.Context) (*resource.Resource, error) {
+	var attrs []attribute.KeyValue
+	for _, v := range k8sEnvVars {
+		for _, name := range v.names {
+			if val := os.Getenv(name); val != "" {
+				attrs = append(attrs, v.key.String(val))
+				break
+			}
+		}
+	}
+	if len(attrs) == 0 {
+		return resource.Empty(), nil
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+}
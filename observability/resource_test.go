@@ -0,0 +1,53 @@
+package observability_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func TestStartObservabilityResource(t *testing.T) {
+	restoreGlobals(t)
+
+	t.Setenv("K8S_POD_NAME", "api-7d9f")
+	t.Setenv("POD_NAMESPACE", "shop")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "team=payments")
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	err := observability.StartObservability(context.Background(),
+		observability.WithServiceName("api"),
+		observability.WithBuildInfo("abc123", "2024-01-01"),
+		observability.WithEnvironment("staging"),
+		observability.WithResourceAttributes(map[string]string{"team": "checkout", "region": "eu"}),
+		observability.WithSignalExporter(observability.SignalTraces, observability.ExporterFile),
+		observability.WithSignalFile(observability.SignalTraces, path),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	require.NoError(t, err)
+	_, span := otel.Tracer("test").Start(context.Background(), "resource-span")
+	span.End()
+	_ = observability.StopObservability(context.Background())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, attr := range []string{
+		`{"Key":"service.name","Value":{"Type":"STRING","Value":"api"}}`,
+		`{"Key":"vcs.ref.head.revision","Value":{"Type":"STRING","Value":"abc123"}}`,
+		`{"Key":"deployment.environment.name","Value":{"Type":"STRING","Value":"staging"}}`,
+		`{"Key":"k8s.pod.name","Value":{"Type":"STRING","Value":"api-7d9f"}}`,
+		`{"Key":"k8s.namespace.name","Value":{"Type":"STRING","Value":"shop"}}`,
+		`{"Key":"region","Value":{"Type":"STRING","Value":"eu"}}`,
+		`{"Key":"team","Value":{"Type":"STRING","Value":"payments"}}`,
+		`{"Key":"process.pid"`,
+	} {
+		assert.Contains(t, string(content), attr)
+	}
+}
@@ -0,0 +1,32 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// registerRuntimeMetrics registers the semconv Go runtime instruments of the contrib instrumentation and the process
+// instruments on the MeterProvider.
+func registerRuntimeMetrics(provider otelmetric.MeterProvider) error {
+	if err := runtime.Start(runtime.WithMeterProvider(provider)); err != nil {
+		return fmt.Errorf("could not create runtime metrics: %w", err)
+	}
+
+	meter := provider.Meter("github.com/davfer/goforarun/observability")
+	var p processMetrics
+	if err := p.create(meter); err != nil {
+		return fmt.Errorf("could not create process metrics: %w", err)
+	}
+	instruments := p.instruments()
+	if len(instruments) == 0 {
+		return nil
+	}
+	_, err := meter.RegisterCallback(func(_ context.Context, o otelmetric.Observer) error {
+		p.observe(o)
+		return nil
+	}, instruments...)
+	return err
+}
@@ -0,0 +1,41 @@
+package observability_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func recordMetrics(t *testing.T, opts ...observability.Customizer) string {
+	f := newFileTelemetry(t, []observability.Signal{observability.SignalMetrics}, opts...)
+	return f.shutdownContent(observability.SignalMetrics)
+}
+
+func TestRuntimeMetrics(t *testing.T) {
+	content := recordMetrics(t)
+
+	for _, name := range []string{"go.goroutine.count", "go.memory.used", "go.memory.allocated", "go.processor.limit"} {
+		assert.Contains(t, content, `"Name":"`+name+`"`)
+	}
+}
+
+func TestProcessMetrics(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the memory and file descriptors are read from /proc")
+	}
+	content := recordMetrics(t)
+
+	for _, name := range []string{"process.cpu.time", "process.memory.usage", "process.open_file_descriptor.count"} {
+		assert.Contains(t, content, `"Name":"`+name+`"`)
+	}
+}
+
+func TestRuntimeMetricsDisabled(t *testing.T) {
+	content := recordMetrics(t, observability.WithRuntimeMetrics(false))
+
+	assert.NotContains(t, content, `"Name":"go.goroutine.count"`)
+	assert.NotContains(t, content, `"Name":"process.cpu.time"`)
+}
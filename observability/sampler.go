@@ -0,0 +1,266 @@
+package observability
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Sampler types, the same names as in OTEL_TRACES_SAMPLER plus the rate limited ones.
+const (
+	SamplerAlwaysOn                = "always_on"
+	SamplerAlwaysOff               = "always_off"
+	SamplerTraceIDRatio            = "traceidratio"
+	SamplerParentBasedAlwaysOn     = "parentbased_always_on"
+	SamplerParentBasedAlwaysOff    = "parentbased_always_off"
+	SamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
+	SamplerRateLimited             = "ratelimited"
+	SamplerParentBasedRateLimited  = "parentbased_ratelimited"
+)
+
+// SamplerOptions configure the sampling of the traces. An empty Type falls back to the OTEL_TRACES_SAMPLER and
+// OTEL_TRACES_SAMPLER_ARG env vars, and then to always_on.
+type SamplerOptions struct {
+	Type string
+	// Arg is the ratio of the ratio samplers, 1 when nil, or the traces per second of the rate limited ones
+	Arg *float64
+	// Rules override the sampler of the matching spans, the first matching rule wins
+	Rules []SamplerRule
+}
+
+// SamplerRule overrides the sampler of the spans matching its span name and route, which are path.Match patterns.
+// The http.route attribute is only known once the request is served, so the route is also matched against url.path,
+// its {name} wildcards matching a segment and a trailing {name...} the rest of the path, as in the ServeMux patterns.
+type SamplerRule struct {
+	SpanName string
+	Route    string
+	Type     string
+	Arg      *float64
+}
+
+func newSampler(o SamplerOptions) (trace.Sampler, error) {
+	if o.Type == "" {
+		o.Type = os.Getenv("OTEL_TRACES_SAMPLER")
+		if arg := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); arg != "" {
+			f, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %s: %w", arg, err)
+			}
+			o.Arg = &f
+		}
+	}
+	if o.Type == "" {
+		o.Type = SamplerAlwaysOn
+	}
+
+	root, err := newTypedSampler(o.Type, o.Arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.Rules) == 0 {
+		return root, nil
+	}
+
+	rules := &rulesSampler{fallback: root}
+	for _, rule := range o.Rules {
+		if _, err = path.Match(rule.SpanName, ""); err != nil {
+			return nil, fmt.Errorf("invalid span name pattern %s: %w", rule.SpanName, err)
+		}
+		segments, rest := routeSegments(rule.Route)
+		if _, err = path.Match(strings.Join(segments, "/"), ""); err != nil {
+			return nil, fmt.Errorf("invalid route pattern %s: %w", rule.Route, err)
+		}
+		s, err := newTypedSampler(rule.Type, rule.Arg)
+		if err != nil {
+			return nil, err
+		}
+		rules.rules = append(rules.rules, samplerRule{
+			spanName:     rule.SpanName,
+			route:        rule.Route,
+			pathSegments: segments,
+			pathRest:     rest,
+			sampler:      s,
+		})
+	}
+	return rules, nil
+}
+
+func newTypedSampler(t string, arg *float64) (trace.Sampler, error) {
+	switch t {
+	case SamplerAlwaysOn:
+		return trace.AlwaysSample(), nil
+	case SamplerAlwaysOff:
+		return trace.NeverSample(), nil
+	case SamplerTraceIDRatio, SamplerParentBasedTraceIDRatio:
+		// as OTEL_TRACES_SAMPLER_ARG, the ratio defaults to 1
+		ratio := 1.0
+		if arg != nil {
+			ratio = *arg
+		}
+		if ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("sampler %s ratio %g not between 0 and 1", t, ratio)
+		}
+		if t == SamplerParentBasedTraceIDRatio {
+			return trace.ParentBased(trace.TraceIDRatioBased(ratio)), nil
+		}
+		return trace.TraceIDRatioBased(ratio), nil
+	case SamplerParentBasedAlwaysOn:
+		return trace.ParentBased(trace.AlwaysSample()), nil
+	case SamplerParentBasedAlwaysOff:
+		return trace.ParentBased(trace.NeverSample()), nil
+	case SamplerRateLimited, SamplerParentBasedRateLimited:
+		if arg == nil || *arg <= 0 {
+			return nil, fmt.Errorf("sampler %s needs a positive number of traces per second", t)
+		}
+		if t == SamplerParentBasedRateLimited {
+			return trace.ParentBased(newRateLimitedSampler(*arg)), nil
+		}
+		return newRateLimitedSampler(*arg), nil
+	default:
+		return nil, fmt.Errorf("sampler %s not supported", t)
+	}
+}
+
+type samplerRule struct {
+	spanName string
+	route    string
+	// pathSegments are the segments of the route matched against url.path, pathRest telling whether a trailing
+	// {name...} wildcard matches the rest of the path
+	pathSegments []string
+	pathRest     bool
+	sampler      trace.Sampler
+}
+
+// routeSegments turns the route into the path.Match patterns of its segments, the {name} wildcards becoming * and
+// {$} the end of the path. A trailing {name...} wildcard is left out and reported by rest.
+func routeSegments(route string) (segments []string, rest bool) {
+	segments = strings.Split(route, "/")
+	for i, s := range segments {
+		if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+			continue
+		}
+		switch {
+		case s == "{$}":
+			segments[i] = ""
+		case strings.HasSuffix(s, "...}") && i == len(segments)-1:
+			return segments[:i], true
+		default:
+			segments[i] = "*"
+		}
+	}
+	return segments, false
+}
+
+func (r samplerRule) matches(p trace.SamplingParameters) bool {
+	if r.spanName != "" {
+		if ok, _ := path.Match(r.spanName, p.Name); !ok {
+			return false
+		}
+	}
+	if r.route != "" {
+		return r.matchesRoute(p.Attributes)
+	}
+	return true
+}
+
+func (r samplerRule) matchesRoute(attrs []attribute.KeyValue) bool {
+	for _, a := range attrs {
+		switch a.Key {
+		case semconv.HTTPRouteKey:
+			if ok, _ := path.Match(r.route, a.Value.AsString()); ok {
+				return true
+			}
+		case semconv.URLPathKey:
+			if r.matchesPath(a.Value.AsString()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r samplerRule) matchesPath(urlPath string) bool {
+	n := len(r.pathSegments)
+	if r.pathRest {
+		// the last part holds the rest of the path, left to the wildcard
+		parts := strings.SplitN(urlPath, "/", n+1)
+		if len(parts) <= n {
+			return false
+		}
+		urlPath = strings.Join(parts[:n], "/")
+	}
+	ok, _ := path.Match(strings.Join(r.pathSegments, "/"), urlPath)
+	return ok
+}
+
+// rulesSampler delegates to the sampler of the first matching rule, or to the fallback one.
+type rulesSampler struct {
+	rules    []samplerRule
+	fallback trace.Sampler
+}
+
+func (s *rulesSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	for _, rule := range s.rules {
+		if rule.matches(p) {
+			return rule.sampler.ShouldSample(p)
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+func (s *rulesSampler) Description() string {
+	return fmt.Sprintf("Rules{rules:%d,fallback:%s}", len(s.rules), s.fallback.Description())
+}
+
+// rateLimitedSampler samples up to perSecond traces per second with a token bucket of a second of capacity.
+type rateLimitedSampler struct {
+	mu         sync.Mutex
+	perSecond  float64
+	capacity   float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newRateLimitedSampler(perSecond float64) *rateLimitedSampler {
+	capacity := max(perSecond, 1)
+	return &rateLimitedSampler{
+		perSecond:  perSecond,
+		capacity:   capacity,
+		tokens:     capacity,
+		lastRefill: time.Now(),
+	}
+}
+
+func (s *rateLimitedSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	s.mu.Lock()
+	now := time.Now()
+	s.tokens += now.Sub(s.lastRefill).Seconds() * s.perSecond
+	if s.tokens > s.capacity {
+		s.tokens = s.capacity
+	}
+	s.lastRefill = now
+	decision := trace.Drop
+	if s.tokens >= 1 {
+		s.tokens--
+		decision = trace.RecordAndSample
+	}
+	s.mu.Unlock()
+
+	return trace.SamplingResult{
+		Decision:   decision,
+		Tracestate: oteltrace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+func (s *rateLimitedSampler) Description() string {
+	return fmt.Sprintf("RateLimited{%g}", s.perSecond)
+}
@@ -0,0 +1,126 @@
+package observability_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func recordSpans(t *testing.T, sampler observability.SamplerOptions, spans func(tracer trace.Tracer)) string {
+	f := newFileTelemetry(t, []observability.Signal{observability.SignalTraces}, observability.WithSampler(sampler))
+	spans(f.TracerProvider.Tracer("test"))
+	return f.shutdownContent(observability.SignalTraces)
+}
+
+func TestSamplerRules(t *testing.T) {
+	content := recordSpans(t, observability.SamplerOptions{
+		Type: observability.SamplerAlwaysOn,
+		Rules: []observability.SamplerRule{
+			{SpanName: "health*", Type: observability.SamplerAlwaysOff},
+			{Route: "/internal/*", Type: observability.SamplerAlwaysOff},
+		},
+	}, func(tracer trace.Tracer) {
+		for _, name := range []string{"healthcheck", "GET /users", "GET /internal/{id}"} {
+			_, span := tracer.Start(context.Background(), name,
+				trace.WithAttributes(semconv.HTTPRoute(strings.TrimPrefix(name, "GET "))))
+			span.End()
+		}
+	})
+
+	assert.NotContains(t, content, `"Name":"healthcheck"`)
+	assert.Contains(t, content, `"Name":"GET /users"`)
+	assert.NotContains(t, content, `"Name":"GET /internal/{id}"`)
+}
+
+func TestSamplerRouteTemplates(t *testing.T) {
+	content := recordSpans(t, observability.SamplerOptions{
+		Type: observability.SamplerAlwaysOn,
+		Rules: []observability.SamplerRule{
+			{Route: "/users/{id}", Type: observability.SamplerAlwaysOff},
+			{Route: "/files/{path...}", Type: observability.SamplerAlwaysOff},
+		},
+	}, func(tracer trace.Tracer) {
+		// as the HTTP instrumentation, only url.path is known when the span starts
+		for _, urlPath := range []string{"/users/42", "/users/42/orders", "/files/a/b", "/files", "/health"} {
+			_, span := tracer.Start(context.Background(), "GET "+urlPath,
+				trace.WithAttributes(semconv.URLPath(urlPath)))
+			span.End()
+		}
+	})
+
+	assert.NotContains(t, content, `"Name":"GET /users/42"`)
+	assert.Contains(t, content, `"Name":"GET /users/42/orders"`)
+	assert.NotContains(t, content, `"Name":"GET /files/a/b"`)
+	assert.Contains(t, content, `"Name":"GET /files"`)
+	assert.Contains(t, content, `"Name":"GET /health"`)
+}
+
+func TestSamplerRateLimited(t *testing.T) {
+	perSecond := 2.0
+	content := recordSpans(t, observability.SamplerOptions{Type: observability.SamplerRateLimited, Arg: &perSecond}, func(tracer trace.Tracer) {
+		for i := 0; i < 10; i++ {
+			_, span := tracer.Start(context.Background(), "limited")
+			span.End()
+		}
+	})
+
+	assert.Equal(t, 2, strings.Count(content, `"Name":"limited"`))
+}
+
+func TestSamplerFromEnv(t *testing.T) {
+	t.Setenv("OTEL_TRACES_SAMPLER", "always_off")
+
+	content := recordSpans(t, observability.SamplerOptions{}, func(tracer trace.Tracer) {
+		_, span := tracer.Start(context.Background(), "never")
+		span.End()
+	})
+
+	assert.Empty(t, content)
+}
+
+func TestSamplerRatioDefault(t *testing.T) {
+	t.Setenv("OTEL_TRACES_SAMPLER", "parentbased_traceidratio")
+
+	content := recordSpans(t, observability.SamplerOptions{}, func(tracer trace.Tracer) {
+		for i := 0; i < 10; i++ {
+			_, span := tracer.Start(context.Background(), "ratio")
+			span.End()
+		}
+	})
+
+	assert.Equal(t, 10, strings.Count(content, `"Name":"ratio"`))
+}
+
+func TestSamplerInvalidArg(t *testing.T) {
+	ratio, perSecond := 1.5, 0.0
+
+	for name, sampler := range map[string]observability.SamplerOptions{
+		"sampler traceidratio ratio 1.5 not between 0 and 1": {Type: observability.SamplerTraceIDRatio, Arg: &ratio},
+		"sampler ratelimited needs a positive number":        {Type: observability.SamplerRateLimited},
+		"sampler parentbased_ratelimited needs a positive":   {Type: observability.SamplerParentBasedRateLimited, Arg: &perSecond},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := observability.NewTelemetry(context.Background(),
+				observability.WithSampler(sampler),
+				observability.WithSignalExporter(observability.SignalTraces, observability.ExporterStdout),
+			)
+			assert.ErrorContains(t, err, name)
+		})
+	}
+}
+
+func TestSamplerValidatedWithoutTraces(t *testing.T) {
+	_, err := observability.NewTelemetry(context.Background(),
+		observability.WithSampler(observability.SamplerOptions{Type: "sometimes"}),
+		observability.WithSignalExporter(observability.SignalTraces, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	assert.ErrorContains(t, err, "sampler sometimes not supported")
+}
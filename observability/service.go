@@ -5,36 +5,144 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	slogmulti "github.com/samber/slog-multi"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/log/global"
-	"go.opentelemetry.io/otel/propagation"
+	lognoop "go.opentelemetry.io/otel/log/noop"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/metric"
-	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
 
 	"github.com/davfer/goforarun/logger"
 )
 
 type Cfg struct {
-	loggerLevel    slog.Leveler
-	loggerChannels map[string]string
-	loggerStdout   bool
-	serviceVersion string
-	serviceName    string
+	loggerLevel           slog.Leveler
+	loggerChannels        map[string]string
+	loggerStdout          bool
+	loggerFormat          string
+	loggerSampling        map[string]logger.SamplingPolicy
+	loggerSamplingReport  time.Duration
+	loggerRedaction       *logger.RedactionPolicy
+	loggerCrashBufferSize int
+	loggerCrashDumpLevel  slog.Leveler
+	otlp                  OTLPOptions
+	signalOTLP            map[Signal]OTLPOptions
+	signalExporter        map[Signal]ExporterKind
+	signalFile            map[Signal]string
+	signalPretty          map[Signal]bool
+	debug                 bool
+	sampler               SamplerOptions
+	failurePolicy         FailurePolicy
+	failureFallback       ExporterKind
+	startupCheck          time.Duration
+	metricsInterval       time.Duration
+	metricsTimeout        time.Duration
+	signalBatch           map[Signal]BatchOptions
+	exemplarFilter        string
+	runtimeMetricsOff     bool
+	prometheus            bool
+	environment           string
+	resourceAttrs         map[string]string
+	buildCommit           string
+	buildDate             string
+	exportFiles           []*os.File
+	degraded              map[Signal]error
+	prometheusRegistry    *prometheus.Registry
+	propagators           []string
+	serviceVersion        string
+	serviceName           string
 }
 
 type Customizer func(*Cfg)
 
+// WithOTLP sets the OTLP exporter options shared by every signal.
+func WithOTLP(o OTLPOptions) Customizer {
+	return func(c *Cfg) {
+		c.otlp = o
+	}
+}
+
+// WithSignalOTLP overrides the shared OTLP exporter options for a signal.
+func WithSignalOTLP(signal Signal, o OTLPOptions) Customizer {
+	return func(c *Cfg) {
+		if c.signalOTLP == nil {
+			c.signalOTLP = make(map[Signal]OTLPOptions)
+		}
+		c.signalOTLP[signal] = o
+	}
+}
+
+// WithSignalExporter sets where the data of a signal is sent, ExporterNone disables it.
+func WithSignalExporter(signal Signal, kind ExporterKind) Customizer {
+	return func(c *Cfg) {
+		if c.signalExporter == nil {
+			c.signalExporter = make(map[Signal]ExporterKind)
+		}
+		c.signalExporter[signal] = kind
+	}
+}
+
+// WithSignalFile sets the path written by the file exporter of a signal.
+func WithSignalFile(signal Signal, path string) Customizer {
+	return func(c *Cfg) {
+		if c.signalFile == nil {
+			c.signalFile = make(map[Signal]string)
+		}
+		c.signalFile[signal] = path
+	}
+}
+
+// WithSignalPrettyPrint indents the output of the stdout exporter of a signal.
+func WithSignalPrettyPrint(signal Signal, pretty bool) Customizer {
+	return func(c *Cfg) {
+		if c.signalPretty == nil {
+			c.signalPretty = make(map[Signal]bool)
+		}
+		c.signalPretty[signal] = pretty
+	}
+}
+
+// WithDebug sends the signals without an exporter nor a collector endpoint to stdout, pretty printed.
+func WithDebug(debug bool) Customizer {
+	return func(c *Cfg) {
+		c.debug = debug
+	}
+}
+
+// WithMetricsInterval sets the time between metric collections and exports, defaults to OTEL_METRIC_EXPORT_INTERVAL
+// or a minute.
+func WithMetricsInterval(interval time.Duration) Customizer {
+	return func(c *Cfg) {
+		c.metricsInterval = interval
+	}
+}
+
+// WithRuntimeMetrics turns on or off the Go runtime and process metrics, on by default.
+func WithRuntimeMetrics(enabled bool) Customizer {
+	return func(c *Cfg) {
+		c.runtimeMetricsOff = !enabled
+	}
+}
+
+func WithSampler(o SamplerOptions) Customizer {
+	return func(c *Cfg) {
+		c.sampler = o
+	}
+}
+
+func WithPropagators(names ...string) Customizer {
+	return func(c *Cfg) {
+		c.propagators = names
+	}
+}
+
 func WithServiceVersion(version string) Customizer {
 	return func(c *Cfg) {
 		c.serviceVersion = version
@@ -60,103 +168,249 @@ func WithLoggerStdout(stdout bool) Customizer {
 		c.loggerStdout = stdout
 	}
 }
+func WithLoggerFormat(format string) Customizer {
+	return func(c *Cfg) {
+		c.loggerFormat = format
+	}
+}
+func WithLoggerRedaction(policy logger.RedactionPolicy) Customizer {
+	return func(c *Cfg) {
+		c.loggerRedaction = &policy
+	}
+}
+func WithLoggerCrashBuffer(size int, dumpLevel slog.Leveler) Customizer {
+	return func(c *Cfg) {
+		c.loggerCrashBufferSize = size
+		c.loggerCrashDumpLevel = dumpLevel
+	}
+}
+func WithLoggerSampling(policies map[string]logger.SamplingPolicy, reportInterval time.Duration) Customizer {
+	return func(c *Cfg) {
+		c.loggerSampling = policies
+		c.loggerSamplingReport = reportInterval
+	}
+}
 
+// StartObservability creates the Telemetry of the options and sets it as the globals.
 func StartObservability(ctx context.Context, opts ...Customizer) error {
+	t, err := NewTelemetry(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	t.SetGlobal()
+	return nil
+}
+
+// NewTelemetry creates the providers and the logger of the options, without touching the globals.
+func NewTelemetry(ctx context.Context, opts ...Customizer) (*Telemetry, error) {
 	c := Cfg{}
 	for _, opt := range opts {
 		opt(&c)
 	}
-
-	var attrs []attribute.KeyValue
-	if hostName, err := os.Hostname(); err == nil {
-		attrs = append(attrs, semconv.HostName(hostName))
-	}
-	if c.serviceName != "" {
-		attrs = append(attrs, semconv.ServiceNameKey.String(c.serviceName))
+	t := &Telemetry{}
+	if err := c.validateFailurePolicy(); err != nil {
+		return nil, err
 	}
-	if c.serviceVersion != "" {
-		attrs = append(attrs, semconv.ServiceVersionKey.String(c.serviceVersion))
+	// the sampler is built even when the traces are off, so that its config is always validated
+	sampler, err := newSampler(c.sampler)
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := resource.New(ctx, resource.WithSchemaURL(semconv.SchemaURL), resource.WithTelemetrySDK(), resource.WithAttributes(attrs...))
+	res, err := c.newResource(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// LOG PARTY
 	var slogHandler slog.Handler
-	if val, ok := os.LookupEnv("OTEL_SDK_DISABLED"); !ok || val != "true" {
-		logExporter, err := otlploggrpc.New(ctx)
-		if err != nil {
-			return err
-		}
-
-		provider := log.NewLoggerProvider(log.WithProcessor(log.NewBatchProcessor(logExporter)), log.WithResource(res))
-		global.SetLoggerProvider(provider)
+	logExporter, err := c.newLogExporter(ctx)
+	if err != nil && c.degrade(SignalLogs, err) {
+		logExporter, err = c.newLogExporter(ctx)
+	}
+	if err != nil {
+		return nil, t.abort(ctx, &c, err)
+	}
+	t.LoggerProvider = lognoop.NewLoggerProvider()
+	if logExporter != nil {
+		t.logStats = &exportStats{}
+		t.loggerProvider = log.NewLoggerProvider(
+			log.WithProcessor(severityTextProcessor{}),
+			log.WithProcessor(&gatedLogProcessor{
+				log.NewBatchProcessor(&countingLogExporter{logExporter, t.logStats}, c.logProcessorOptions()...),
+				queueGate{int64(c.queueSize(SignalLogs, "OTEL_BLRP_MAX_QUEUE_SIZE")), t.logStats},
+			}),
+			log.WithResource(res),
+		)
+		t.LoggerProvider = t.loggerProvider
 
-		slogHandler = otelslog.NewHandler(c.serviceName, otelslog.WithLoggerProvider(global.GetLoggerProvider()))
+		slogHandler = otelslog.NewHandler(c.serviceName, otelslog.WithLoggerProvider(t.LoggerProvider))
+		// the crash buffer keeps the records rejected by every sink, so the OTLP one is leveled too when it is on
+		if c.loggerLevel != nil && c.loggerCrashBufferSize > 0 {
+			slogHandler = logger.NewLeveledHandler(slogHandler, c.loggerLevel)
+		}
 	}
 	if c.loggerStdout {
 		l := (slog.Leveler)(slog.LevelDebug)
-		if c.loggerLevel.Level() >= 0 {
+		if c.loggerLevel != nil {
 			l = c.loggerLevel
 		}
-		textHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: l})
+		// the level is checked by the leveled handler so the crash buffer can dump below it
+		handlerOpts := &slog.HandlerOptions{Level: slog.Level(math.MinInt), ReplaceAttr: logger.ReplaceLevelName}
+		var textHandler slog.Handler = slog.NewTextHandler(os.Stdout, handlerOpts)
+		if c.loggerFormat == "json" {
+			textHandler = slog.NewJSONHandler(os.Stdout, handlerOpts)
+		}
+		textHandler = logger.NewLeveledHandler(textHandler, l)
 		if slogHandler != nil {
 			slogHandler = slogmulti.Fanout(slogHandler, textHandler)
 		} else {
 			slogHandler = textHandler
 		}
 	}
+	if slogHandler == nil {
+		slogHandler = slog.DiscardHandler
+	}
 	if len(c.loggerChannels) > 0 {
 		var m map[string]slog.Leveler
 		m, err = mapToLeveler(c.loggerChannels)
 		if err != nil {
-			return err
+			return nil, t.abort(ctx, &c, err)
 		}
 
 		slogHandler = logger.NewChanneledHandler(slogHandler, m)
 	}
+	if len(c.loggerSampling) > 0 {
+		for channel, policy := range c.loggerSampling {
+			if err = policy.Validate(); err != nil {
+				return nil, t.abort(ctx, &c, fmt.Errorf("invalid sampling of channel %s: %w", channel, err))
+			}
+		}
 
-	slog.SetDefault(slog.New(slogHandler))
+		slogHandler = logger.NewSamplingHandler(slogHandler, c.loggerSampling, c.loggerSamplingReport)
+		t.sampling = slogHandler.(*logger.SamplingHandler)
+	}
+	if c.loggerRedaction != nil {
+		slogHandler = logger.NewRedactingHandler(slogHandler, *c.loggerRedaction)
+	}
+	if c.loggerCrashBufferSize > 0 {
+		t.crashBuffer = logger.NewRingBufferHandler(slogHandler, c.loggerCrashBufferSize, c.loggerCrashDumpLevel)
+		slogHandler = t.crashBuffer
+	}
+	t.handler = slogHandler
 
 	// TRACE PARTY
-	if val, ok := os.LookupEnv("OTEL_SDK_DISABLED"); !ok || val != "true" {
-		traceExporter, err := otlptracegrpc.New(ctx)
-		if err != nil {
-			return err
+	traceExporter, err := c.newSpanExporter(ctx)
+	if err != nil && c.degrade(SignalTraces, err) {
+		traceExporter, err = c.newSpanExporter(ctx)
+	}
+	if err != nil {
+		return nil, t.abort(ctx, &c, err)
+	}
+	t.TracerProvider = tracenoop.NewTracerProvider()
+	if traceExporter != nil {
+		t.spanStats = &exportStats{}
+		bsp := &gatedSpanProcessor{
+			trace.NewBatchSpanProcessor(&countingSpanExporter{traceExporter, t.spanStats}, c.spanProcessorOptions()...),
+			queueGate{int64(c.queueSize(SignalTraces, "OTEL_BSP_MAX_QUEUE_SIZE")), t.spanStats},
 		}
+		t.tracerProvider = trace.NewTracerProvider(trace.WithSampler(sampler), trace.WithResource(res), trace.WithSpanProcessor(bsp))
+		t.TracerProvider = t.tracerProvider
+	}
 
-		bsp := trace.NewBatchSpanProcessor(traceExporter)
-		tracerProvider := trace.NewTracerProvider(trace.WithSampler(trace.AlwaysSample()), trace.WithResource(res), trace.WithSpanProcessor(bsp))
-		otel.SetTracerProvider(tracerProvider)
-		otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Propagator, err = newPropagator(c.propagators)
+	if err != nil {
+		return nil, t.abort(ctx, &c, err)
 	}
 
 	// METER PARTY
-	if val, ok := os.LookupEnv("OTEL_SDK_DISABLED"); !ok || val != "true" {
-		metricExporter, err := otlpmetricgrpc.New(ctx)
+	metricExporter, err := c.newMetricExporter(ctx)
+	if err != nil && c.degrade(SignalMetrics, err) {
+		metricExporter, err = c.newMetricExporter(ctx)
+	}
+	if err != nil {
+		return nil, t.abort(ctx, &c, err)
+	}
+	prometheusReader, err := c.newPrometheusReader()
+	if err != nil {
+		return nil, t.abort(ctx, &c, err)
+	}
+	t.MeterProvider = metricnoop.NewMeterProvider()
+	if metricExporter != nil || prometheusReader != nil {
+		meterOpts, err := c.exemplarOptions()
 		if err != nil {
-			return err
+			return nil, t.abort(ctx, &c, err)
+		}
+		meterOpts = append(meterOpts, metric.WithResource(res))
+		if metricExporter != nil {
+			var readerOpts []metric.PeriodicReaderOption
+			if c.metricsInterval > 0 {
+				readerOpts = append(readerOpts, metric.WithInterval(c.metricsInterval))
+			}
+			if c.metricsTimeout > 0 {
+				readerOpts = append(readerOpts, metric.WithTimeout(c.metricsTimeout))
+			}
+			meterOpts = append(meterOpts, metric.WithReader(metric.NewPeriodicReader(metricExporter, readerOpts...)))
+		}
+		if prometheusReader != nil {
+			meterOpts = append(meterOpts, metric.WithReader(prometheusReader))
+			t.prometheusRegistry = c.prometheusRegistry
+		}
+		t.meterProvider = metric.NewMeterProvider(meterOpts...)
+		t.MeterProvider = t.meterProvider
+
+		if !c.runtimeMetricsOff {
+			if err = registerRuntimeMetrics(t.MeterProvider); err != nil {
+				return nil, t.abort(ctx, &c, err)
+			}
+		}
+		if err = t.registerExportMetrics(); err != nil {
+			return nil, t.abort(ctx, &c, err)
+		}
+		if t.sampling != nil {
+			if err = t.sampling.RegisterMetrics(t.MeterProvider); err != nil {
+				return nil, t.abort(ctx, &c, err)
+			}
 		}
-		meterProvider := metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(metricExporter)), metric.WithResource(res))
-		otel.SetMeterProvider(meterProvider)
 	}
-	return nil
-}
 
-func StopObservability(ctx context.Context) (err error) {
-	if t, ok := otel.GetTracerProvider().(*trace.TracerProvider); ok {
-		err = errors.Join(t.Shutdown(ctx))
+	t.exportFiles = c.exportFiles
+	t.degraded = c.degraded
+	if err = t.reportDegraded(); err != nil {
+		return nil, t.abort(ctx, &c, err)
 	}
-	if m, ok := otel.GetMeterProvider().(*metric.MeterProvider); ok {
-		err = errors.Join(m.Shutdown(ctx))
+	return t, nil
+}
+
+// abort shuts down what was created before the error.
+func (t *Telemetry) abort(ctx context.Context, c *Cfg, err error) error {
+	t.exportFiles = c.exportFiles
+	return errors.Join(err, t.Shutdown(ctx))
+}
+
+// DumpCrashBuffer writes the records kept by the crash buffer of the global Telemetry to the log sinks, if it is
+// enabled.
+func DumpCrashBuffer(ctx context.Context) {
+	if current != nil {
+		current.DumpCrashBuffer(ctx)
 	}
-	if l, ok := global.GetLoggerProvider().(*log.LoggerProvider); ok {
-		err = errors.Join(l.Shutdown(ctx))
+}
+
+// ForceFlush exports the buffered data of the global Telemetry, see Telemetry.ForceFlush.
+func ForceFlush(ctx context.Context) error {
+	if current == nil {
+		return nil
 	}
+	return current.ForceFlush(ctx)
+}
 
-	return
+// StopObservability shuts down the global Telemetry.
+func StopObservability(ctx context.Context) error {
+	if current == nil {
+		return nil
+	}
+	return current.Shutdown(ctx)
 }
 
 func mapToLeveler(m map[string]string) (res map[string]slog.Leveler, err error) {
@@ -173,18 +427,7 @@ func mapToLeveler(m map[string]string) (res map[string]slog.Leveler, err error)
 	return
 }
 
-func ParseLevel(s string) (l slog.Leveler, err error) {
-	switch s {
-	case "debug":
-		l = slog.LevelDebug
-	case "info":
-		l = slog.LevelInfo
-	case "warn":
-		l = slog.LevelWarn
-	case "error":
-		l = slog.LevelError
-	default:
-		err = fmt.Errorf("level %s not supported", s)
-	}
-	return
+// ParseLevel parses a log level, see logger.ParseLevel for the accepted values.
+func ParseLevel(s string) (slog.Leveler, error) {
+	return logger.ParseLevel(s)
 }
@@ -0,0 +1,38 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/sdk/log"
+
+	"github.com/davfer/goforarun/logger"
+)
+
+// severityOffset is the distance between the slog levels and the OTel severities used by the otelslog bridge.
+const severityOffset = int(otellog.SeverityDebug) - int(slog.LevelDebug)
+
+var _ log.Processor = severityTextProcessor{}
+
+// severityTextProcessor rewrites the severity text set by the otelslog bridge ("ERROR+4") with the framework level
+// names ("FATAL"). It has to be registered before the exporting processor.
+type severityTextProcessor struct{}
+
+// Enabled leaves the decision to the exporting processor, OnEmit being called regardless.
+func (p severityTextProcessor) Enabled(context.Context, log.EnabledParameters) bool {
+	return false
+}
+
+func (p severityTextProcessor) OnEmit(_ context.Context, record *log.Record) error {
+	record.SetSeverityText(logger.LevelName(slog.Level(int(record.Severity()) - severityOffset)))
+	return nil
+}
+
+func (p severityTextProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (p severityTextProcessor) ForceFlush(context.Context) error {
+	return nil
+}
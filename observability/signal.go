@@ -0,0 +1,186 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ExporterKind is where the data of a signal is sent.
+type ExporterKind string
+
+const (
+	ExporterOTLP   ExporterKind = "otlp"
+	ExporterStdout ExporterKind = "stdout"
+	ExporterFile   ExporterKind = "file"
+	ExporterNone   ExporterKind = "none"
+)
+
+// exporterKind resolves the exporter of the signal from the options, then the OTEL_SDK_DISABLED and
+// OTEL_{SIGNAL}_EXPORTER env vars, defaulting to otlp. In debug mode, without a collector endpoint, traces and
+// metrics default to stdout and logs to none, as they are already written by the stdout logger.
+func (c *Cfg) exporterKind(signal Signal) (ExporterKind, error) {
+	if val, ok := os.LookupEnv("OTEL_SDK_DISABLED"); ok && val == "true" {
+		return ExporterNone, nil
+	}
+
+	kind := c.signalExporter[signal]
+	if kind == "" {
+		switch env := os.Getenv("OTEL_" + strings.ToUpper(string(signal)) + "_EXPORTER"); env {
+		case "console":
+			kind = ExporterStdout
+		default:
+			kind = ExporterKind(env)
+		}
+	}
+
+	if kind == "" && c.debug && !c.hasCollectorEndpoint(signal) {
+		kind = ExporterStdout
+		if signal == SignalLogs && c.loggerStdout {
+			kind = ExporterNone
+		}
+	}
+
+	switch kind {
+	case "", ExporterOTLP:
+		return ExporterOTLP, nil
+	case ExporterStdout, ExporterNone:
+		return kind, nil
+	case ExporterFile:
+		if c.signalFile[signal] == "" {
+			return "", fmt.Errorf("file exporter of %s needs a path", signal)
+		}
+		return kind, nil
+	default:
+		return "", fmt.Errorf("exporter %s of %s not supported", kind, signal)
+	}
+}
+
+// hasCollectorEndpoint reports whether an OTLP endpoint is configured for the signal, in the options or the env.
+func (c *Cfg) hasCollectorEndpoint(signal Signal) bool {
+	return c.otlp.merge(c.signalOTLP[signal]).Endpoint != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_"+strings.ToUpper(string(signal))+"_ENDPOINT") != ""
+}
+
+// prettyPrint reports whether the stdout exporter of the signal indents its output, which is the default in debug
+// mode. The file exporters always write JSON lines.
+func (c *Cfg) prettyPrint(kind ExporterKind, signal Signal) bool {
+	if kind != ExporterStdout {
+		return false
+	}
+	if pretty, ok := c.signalPretty[signal]; ok {
+		return pretty
+	}
+	return c.debug
+}
+
+// exportWriter returns where the stdout and file exporters of the signal write.
+func (c *Cfg) exportWriter(kind ExporterKind, signal Signal) (io.Writer, error) {
+	if kind == ExporterStdout {
+		return os.Stdout, nil
+	}
+
+	f, err := os.OpenFile(c.signalFile[signal], os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s export file: %w", signal, err)
+	}
+	c.exportFiles = append(c.exportFiles, f)
+	return f, nil
+}
+
+// newSpanExporter creates the exporter of the traces, nil when they are not exported.
+func (c *Cfg) newSpanExporter(ctx context.Context) (trace.SpanExporter, error) {
+	kind, err := c.exporterKind(SignalTraces)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case ExporterOTLP:
+		o := c.otlp.merge(c.signalOTLP[SignalTraces])
+		if err = c.checkCollector(ctx, SignalTraces, o); err != nil {
+			return nil, err
+		}
+		return newOTLPTraceExporter(ctx, o)
+	case ExporterStdout, ExporterFile:
+		w, err := c.exportWriter(kind, SignalTraces)
+		if err != nil {
+			return nil, err
+		}
+		opts := []stdouttrace.Option{stdouttrace.WithWriter(w)}
+		if c.prettyPrint(kind, SignalTraces) {
+			opts = append(opts, stdouttrace.WithPrettyPrint())
+		}
+		return stdouttrace.New(opts...)
+	default:
+		return nil, nil
+	}
+}
+
+// newMetricExporter creates the exporter of the metrics, nil when they are not exported.
+func (c *Cfg) newMetricExporter(ctx context.Context) (metric.Exporter, error) {
+	kind, err := c.exporterKind(SignalMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case ExporterOTLP:
+		o := c.otlp.merge(c.signalOTLP[SignalMetrics])
+		if err = c.checkCollector(ctx, SignalMetrics, o); err != nil {
+			return nil, err
+		}
+		return newOTLPMetricExporter(ctx, o)
+	case ExporterStdout, ExporterFile:
+		w, err := c.exportWriter(kind, SignalMetrics)
+		if err != nil {
+			return nil, err
+		}
+		opts := []stdoutmetric.Option{stdoutmetric.WithWriter(w)}
+		if c.prettyPrint(kind, SignalMetrics) {
+			opts = append(opts, stdoutmetric.WithPrettyPrint())
+		}
+		return stdoutmetric.New(opts...)
+	default:
+		return nil, nil
+	}
+}
+
+// newLogExporter creates the exporter of the logs, nil when they are not exported.
+func (c *Cfg) newLogExporter(ctx context.Context) (log.Exporter, error) {
+	kind, err := c.exporterKind(SignalLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case ExporterOTLP:
+		o := c.otlp.merge(c.signalOTLP[SignalLogs])
+		if err = c.checkCollector(ctx, SignalLogs, o); err != nil {
+			return nil, err
+		}
+		return newOTLPLogExporter(ctx, o)
+	case ExporterStdout, ExporterFile:
+		w, err := c.exportWriter(kind, SignalLogs)
+		if err != nil {
+			return nil, err
+		}
+		opts := []stdoutlog.Option{stdoutlog.WithWriter(w)}
+		if c.prettyPrint(kind, SignalLogs) {
+			opts = append(opts, stdoutlog.WithPrettyPrint())
+		}
+		return stdoutlog.New(opts...)
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,109 @@
+package observability_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func TestFileExporter(t *testing.T) {
+	content := recordSpans(t, observability.SamplerOptions{}, func(tracer trace.Tracer) {
+		_, span := tracer.Start(context.Background(), "file-span")
+		span.End()
+	})
+
+	assert.Contains(t, content, `"Name":"file-span"`)
+}
+
+func TestStartObservabilityExporterErrors(t *testing.T) {
+	restoreGlobals(t)
+
+	err := observability.StartObservability(context.Background(),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterFile),
+	)
+	assert.ErrorContains(t, err, "file exporter of logs needs a path")
+
+	err = observability.StartObservability(context.Background(),
+		observability.WithSignalExporter(observability.SignalTraces, "zipkin"),
+	)
+	assert.ErrorContains(t, err, "exporter zipkin of traces not supported")
+}
+
+// captureOutput returns what f writes to the file, e.g. os.Stdout. The pipe is drained while f runs, so f does not
+// block on a full pipe, and the globals f sets are restored after the test.
+func captureOutput(t *testing.T, file **os.File, f func()) string {
+	restoreGlobals(t)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	out := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- b
+	}()
+
+	original := *file
+	*file = w
+	defer func() { *file = original }()
+
+	f()
+	require.NoError(t, w.Close())
+	return string(<-out)
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	return captureOutput(t, &os.Stdout, f)
+}
+
+func TestStartObservabilityDebug(t *testing.T) {
+	out := captureStdout(t, func() {
+		err := observability.StartObservability(context.Background(),
+			observability.WithDebug(true),
+			observability.WithLoggerStdout(true),
+		)
+		require.NoError(t, err)
+
+		ctx, span := otel.Tracer("test").Start(context.Background(), "debug-span")
+		slog.InfoContext(ctx, "debug-log")
+		span.End()
+		_ = observability.StopObservability(context.Background())
+	})
+
+	assert.Contains(t, out, `"Name": "debug-span"`)
+	assert.Contains(t, out, "level=INFO msg=debug-log")
+	assert.NotContains(t, out, `"Body"`)
+}
+
+func TestStartObservabilityDebugWithCollector(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://localhost:4317")
+
+	out := captureStdout(t, func() {
+		err := observability.StartObservability(context.Background(),
+			observability.WithDebug(true),
+			observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+			observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+		)
+		require.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "collected-span")
+		span.End()
+
+		// nothing listens on the collector endpoint
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_ = observability.StopObservability(ctx)
+	})
+
+	assert.NotContains(t, out, "collected-span")
+}
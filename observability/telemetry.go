@@ -0,0 +1,124 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/davfer/goforarun/logger"
+)
+
+type telemetryCtxKey struct{}
+
+// current is the Telemetry set as the globals, used by the package level functions.
+var current *Telemetry
+
+// Telemetry holds the providers and the logger of a service. The signals without exporter get no-op providers.
+type Telemetry struct {
+	TracerProvider oteltrace.TracerProvider
+	MeterProvider  otelmetric.MeterProvider
+	LoggerProvider otellog.LoggerProvider
+	Propagator     propagation.TextMapPropagator
+
+	handler            slog.Handler
+	tracerProvider     *trace.TracerProvider
+	meterProvider      *metric.MeterProvider
+	loggerProvider     *log.LoggerProvider
+	spanStats          *exportStats
+	logStats           *exportStats
+	crashBuffer        *logger.RingBufferHandler
+	sampling           *logger.SamplingHandler
+	exportFiles        []*os.File
+	degraded           map[Signal]error
+	prometheusRegistry *prometheus.Registry
+}
+
+// SetGlobal sets the providers, the propagator and the logger of the Telemetry as the otel, otel log and slog
+// globals, and flushes it on logger.Fatal.
+func (t *Telemetry) SetGlobal() {
+	otel.SetTracerProvider(t.TracerProvider)
+	otel.SetMeterProvider(t.MeterProvider)
+	global.SetLoggerProvider(t.LoggerProvider)
+	otel.SetTextMapPropagator(t.Propagator)
+	slog.SetDefault(slog.New(t.handler))
+	logger.SetFatalHook(func(ctx context.Context) error {
+		t.DumpCrashBuffer(ctx)
+		return t.Shutdown(ctx)
+	})
+
+	current = t
+}
+
+// Handler returns the slog handler writing to the log sinks of the Telemetry.
+func (t *Telemetry) Handler() slog.Handler {
+	return t.handler
+}
+
+// Logger returns a logger of the channel, like logger.Get does with the default one.
+func (t *Telemetry) Logger(channel string, attrs ...any) *slog.Logger {
+	return slog.New(t.handler).With(slog.String("channel", channel)).With(attrs...)
+}
+
+// DumpCrashBuffer writes the records kept by the crash buffer to the log sinks, if it is enabled.
+func (t *Telemetry) DumpCrashBuffer(ctx context.Context) {
+	if t.crashBuffer != nil {
+		t.crashBuffer.Dump(ctx)
+	}
+}
+
+// Degraded returns the signals running on the fallback exporter, with the error of their configured exporter. It is
+// empty when the observability is healthy.
+func (t *Telemetry) Degraded() map[Signal]error {
+	return maps.Clone(t.degraded)
+}
+
+// PrometheusHandler serves the metrics in the Prometheus exposition format, nil when the Prometheus reader is
+// disabled.
+func (t *Telemetry) PrometheusHandler() http.Handler {
+	if t.prometheusRegistry == nil {
+		return nil
+	}
+	return promhttp.HandlerFor(t.prometheusRegistry, promhttp.HandlerOpts{})
+}
+
+// ContextWithTelemetry returns a copy of the context carrying the Telemetry.
+func ContextWithTelemetry(ctx context.Context, t *Telemetry) context.Context {
+	return context.WithValue(ctx, telemetryCtxKey{}, t)
+}
+
+// FromContext returns the Telemetry of the context, or the global one when there is none.
+func FromContext(ctx context.Context) *Telemetry {
+	if t, ok := ctx.Value(telemetryCtxKey{}).(*Telemetry); ok {
+		return t
+	}
+	return Global()
+}
+
+// Global returns the Telemetry set as the globals, or one wrapping the otel and slog globals when SetGlobal was not
+// called.
+func Global() *Telemetry {
+	if current != nil {
+		return current
+	}
+	return &Telemetry{
+		TracerProvider: otel.GetTracerProvider(),
+		MeterProvider:  otel.GetMeterProvider(),
+		LoggerProvider: global.GetLoggerProvider(),
+		Propagator:     otel.GetTextMapPropagator(),
+		handler:        slog.Default().Handler(),
+	}
+}
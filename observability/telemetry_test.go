@@ -0,0 +1,45 @@
+package observability_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func TestNewTelemetryWithoutGlobals(t *testing.T) {
+	first := newFileTelemetry(t, []observability.Signal{observability.SignalTraces})
+	second := newFileTelemetry(t, []observability.Signal{observability.SignalTraces})
+	global := otel.GetTracerProvider()
+
+	_, span := first.TracerProvider.Tracer("test").Start(context.Background(), "first-span")
+	span.End()
+	_, span = second.TracerProvider.Tracer("test").Start(context.Background(), "second-span")
+	span.End()
+
+	require.NoError(t, first.Shutdown(context.Background()))
+	require.NoError(t, second.Shutdown(context.Background()))
+	assert.Equal(t, global, otel.GetTracerProvider())
+
+	content := first.content(observability.SignalTraces)
+	assert.Contains(t, content, `"Name":"first-span"`)
+	assert.NotContains(t, content, `"Name":"second-span"`)
+}
+
+func TestTelemetryFromContext(t *testing.T) {
+	restoreGlobals(t)
+
+	tel := newFileTelemetry(t, []observability.Signal{observability.SignalTraces}).Telemetry
+	defer func() { _ = tel.Shutdown(context.Background()) }()
+
+	ctx := observability.ContextWithTelemetry(context.Background(), tel)
+	assert.Same(t, tel, observability.FromContext(ctx))
+
+	tel.SetGlobal()
+	assert.Same(t, tel, observability.FromContext(context.Background()))
+	assert.Equal(t, tel.TracerProvider, otel.GetTracerProvider())
+}
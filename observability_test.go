@@ -0,0 +1,24 @@
+package goforarun
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPConfigRetryEnabledByDefault(t *testing.T) {
+	disabled := false
+
+	retry := OTLPConfig{Retry: &OTLPRetryConfig{InitialInterval: time.Second}}.options().Retry
+	require.NotNil(t, retry)
+	assert.True(t, retry.Enabled)
+	assert.Equal(t, time.Second, retry.InitialInterval)
+
+	retry = OTLPConfig{Retry: &OTLPRetryConfig{Enabled: &disabled}}.options().Retry
+	require.NotNil(t, retry)
+	assert.False(t, retry.Enabled)
+
+	assert.Nil(t, OTLPConfig{}.options().Retry)
+}
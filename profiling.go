@@ -0,0 +1,122 @@
+package goforarun
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/davfer/goforarun/observability"
+	"github.com/davfer/goforarun/profiling"
+)
+
+// ProfilerServerName is the name of the framework server capturing the continuous profiles.
+const ProfilerServerName = "profiler"
+
+// ProfilingConfig is the configuration of the continuous profiling, capturing CPU and heap profiles in the background
+// to a rotating directory or an HTTP endpoint.
+type ProfilingConfig struct {
+	// Enabled starts the profiler
+	Enabled bool `yaml:"enabled"`
+	// Interval is the period of the captures, 0 only captures on the trigger thresholds
+	Interval time.Duration `yaml:"interval"`
+	// Profiles are the kinds captured periodically (cpu, heap), default both
+	Profiles []string `yaml:"profiles"`
+	// CPUDuration is how long a CPU profile samples (default 10s)
+	CPUDuration time.Duration `yaml:"cpu_duration"`
+	// Dir is the directory the profiles are written to (default <tmp>/<service_name>-profiles)
+	Dir string `yaml:"dir"`
+	// MaxFiles is the number of profiles kept in Dir (default 20)
+	MaxFiles int `yaml:"max_files"`
+	// Endpoint is the URL the profiles are posted to instead of Dir
+	Endpoint string `yaml:"endpoint"`
+	// Trigger captures a profile when the CPU or the heap cross a threshold
+	Trigger ProfilingTriggerConfig `yaml:"trigger"`
+}
+
+// ProfilingTriggerConfig is the configuration of the captures on usage thresholds, a zero threshold disables its
+// trigger.
+type ProfilingTriggerConfig struct {
+	// CPU is the fraction of the GOMAXPROCS CPUs used that captures a CPU profile, between 0 and 1
+	CPU float64 `yaml:"cpu"`
+	// HeapBytes is the size of the heap objects that captures a heap profile
+	HeapBytes uint64 `yaml:"heap_bytes"`
+	// CheckInterval is how often the usage is checked (default 10s)
+	CheckInterval time.Duration `yaml:"check_interval"`
+	// Cooldown is the minimum time between two triggered captures of a kind (default 5m)
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+// profilerServer runs the profiler, managed by the framework alongside the app servers.
+type profilerServer struct {
+	info     *InfoServer
+	profiler *profiling.Profiler
+	logger   *slog.Logger
+}
+
+func newProfilerServer(c ProfilingConfig, cfg *BaseAppConfig, t *observability.Telemetry) (*profilerServer, error) {
+	var (
+		sink profiling.Sink
+		err  error
+	)
+	if c.Endpoint != "" {
+		sink, err = profiling.NewHTTPSink(c.Endpoint, nil)
+	} else {
+		dir := c.Dir
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), cfg.ServiceName+"-profiles")
+		}
+		sink, err = profiling.NewDirSink(dir, c.MaxFiles)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var version string
+	if cfg.BuildInfo != nil {
+		version = cfg.BuildInfo.Version
+	}
+	kinds := make([]profiling.Kind, 0, len(c.Profiles))
+	for _, k := range c.Profiles {
+		kinds = append(kinds, profiling.Kind(k))
+	}
+
+	l := t.Logger(AppLoggerName, slog.String("server", ProfilerServerName))
+	profiler, err := profiling.New(profiling.Options{
+		Service:     cfg.ServiceName,
+		Version:     version,
+		Interval:    c.Interval,
+		Kinds:       kinds,
+		CPUDuration: c.CPUDuration,
+		Trigger: profiling.Trigger{
+			CPU:           c.Trigger.CPU,
+			HeapBytes:     c.Trigger.HeapBytes,
+			CheckInterval: c.Trigger.CheckInterval,
+			Cooldown:      c.Trigger.Cooldown,
+		},
+		Sink: sink,
+	}, l)
+	if err != nil {
+		return nil, err
+	}
+
+	return &profilerServer{
+		info:     &InfoServer{Name: ProfilerServerName},
+		profiler: profiler,
+		logger:   l,
+	}, nil
+}
+
+func (s *profilerServer) Run(ctx context.Context) error {
+	s.logger.Info("starting profiler")
+	return s.profiler.Run(ctx)
+}
+
+func (s *profilerServer) Shutdown(ctx context.Context) error {
+	return s.profiler.Shutdown(ctx)
+}
+
+func (s *profilerServer) Info() *InfoServer {
+	return s.info
+}
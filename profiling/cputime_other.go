@@ -0,0 +1,10 @@
+//go:build !unix
+
+package profiling
+
+import "time"
+
+// cpuTime is not reported on this platform, the CPU trigger never fires.
+func cpuTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,265 @@
+// Package profiling captures CPU and heap profiles in the background, periodically or when the usage of the process
+// crosses a threshold, and hands them to a Sink.
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// Kind is the type of a profile.
+type Kind string
+
+const (
+	// KindCPU samples the CPU usage of the process for the CPU duration.
+	KindCPU Kind = "cpu"
+	// KindHeap is a snapshot of the heap allocations, as of the last garbage collection.
+	KindHeap Kind = "heap"
+)
+
+// Reasons of the captures.
+const (
+	ReasonPeriodic      = "periodic"
+	ReasonCPUThreshold  = "cpu_threshold"
+	ReasonHeapThreshold = "heap_threshold"
+)
+
+// Defaults used for the options left empty.
+const (
+	DefaultCPUDuration   = 10 * time.Second
+	DefaultCheckInterval = 10 * time.Second
+	DefaultCooldown      = 5 * time.Minute
+)
+
+// Profile is a captured profile in the gzipped pprof format, tagged with the service it comes from.
+type Profile struct {
+	Kind    Kind
+	Reason  string
+	Service string
+	Version string
+	Time    time.Time
+	Data    []byte
+}
+
+// Options describe what the Profiler captures, when, and where it sends the profiles.
+type Options struct {
+	// Service is the name of the service tagging the profiles
+	Service string
+	// Version is the version of the service tagging the profiles
+	Version string
+	// Interval is the period of the captures of every kind, 0 disables the periodic captures
+	Interval time.Duration
+	// Kinds are the profiles captured periodically (default cpu and heap)
+	Kinds []Kind
+	// CPUDuration is how long a CPU profile samples (default 10s)
+	CPUDuration time.Duration
+	// Trigger captures profiles when the usage crosses a threshold
+	Trigger Trigger
+	// Sink receives the profiles
+	Sink Sink
+}
+
+// Trigger captures a CPU profile when the CPU usage, and a heap profile when the heap, reach their threshold. A zero
+// threshold disables its trigger.
+type Trigger struct {
+	// CPU is the fraction of the GOMAXPROCS CPUs used by the process, between 0 and 1
+	CPU float64
+	// HeapBytes is the size of the heap objects
+	HeapBytes uint64
+	// CheckInterval is how often the usage is checked (default 10s)
+	CheckInterval time.Duration
+	// Cooldown is the minimum time between two triggered captures of a kind (default 5m)
+	Cooldown time.Duration
+}
+
+func (t Trigger) enabled() bool {
+	return t.CPU > 0 || t.HeapBytes > 0
+}
+
+// Profiler captures the profiles until its Run context is done or it is shut down. Captures never overlap, as the
+// runtime allows a single CPU profile at a time.
+type Profiler struct {
+	opts   Options
+	logger *slog.Logger
+	usage  *usageSampler
+
+	captureMu sync.Mutex
+	mu        sync.Mutex
+	cancel    context.CancelFunc
+	done      chan struct{}
+	triggered map[Kind]time.Time
+}
+
+// New creates a Profiler, logging the failed captures to l.
+func New(opts Options, l *slog.Logger) (*Profiler, error) {
+	if opts.Sink == nil {
+		return nil, errors.New("profiling needs a sink")
+	}
+	if opts.Interval <= 0 && !opts.Trigger.enabled() {
+		return nil, errors.New("profiling needs an interval or a trigger threshold")
+	}
+	if opts.Trigger.CPU < 0 || opts.Trigger.CPU > 1 {
+		return nil, fmt.Errorf("invalid cpu threshold %g, must be between 0 and 1", opts.Trigger.CPU)
+	}
+	if len(opts.Kinds) == 0 {
+		opts.Kinds = []Kind{KindCPU, KindHeap}
+	}
+	for _, k := range opts.Kinds {
+		if k != KindCPU && k != KindHeap {
+			return nil, fmt.Errorf("unknown profile kind %s", k)
+		}
+	}
+	if opts.CPUDuration <= 0 {
+		opts.CPUDuration = DefaultCPUDuration
+	}
+	if opts.Trigger.CheckInterval <= 0 {
+		opts.Trigger.CheckInterval = DefaultCheckInterval
+	}
+	if opts.Trigger.Cooldown <= 0 {
+		opts.Trigger.Cooldown = DefaultCooldown
+	}
+
+	return &Profiler{
+		opts:      opts,
+		logger:    l,
+		usage:     newUsageSampler(),
+		triggered: make(map[Kind]time.Time),
+	}, nil
+}
+
+// Run captures the profiles until ctx is done or Shutdown is called.
+func (p *Profiler) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	p.mu.Lock()
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	p.mu.Unlock()
+	defer close(p.done)
+	defer cancel()
+
+	var periodic, check <-chan time.Time
+	if p.opts.Interval > 0 {
+		ticker := time.NewTicker(p.opts.Interval)
+		defer ticker.Stop()
+		periodic = ticker.C
+	}
+	if p.opts.Trigger.enabled() {
+		ticker := time.NewTicker(p.opts.Trigger.CheckInterval)
+		defer ticker.Stop()
+		check = ticker.C
+		p.usage.sample()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-periodic:
+			for _, k := range p.opts.Kinds {
+				p.capture(ctx, k, ReasonPeriodic)
+			}
+		case <-check:
+			p.checkTrigger(ctx)
+		}
+	}
+}
+
+// Shutdown stops the Profiler, discarding the CPU profile being captured, and waits for Run to return.
+func (p *Profiler) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Capture captures a profile of the kind and sends it to the sink.
+func (p *Profiler) Capture(ctx context.Context, kind Kind, reason string) error {
+	p.captureMu.Lock()
+	defer p.captureMu.Unlock()
+
+	var buf bytes.Buffer
+	switch kind {
+	case KindCPU:
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return fmt.Errorf("could not start cpu profile: %w", err)
+		}
+		timer := time.NewTimer(p.opts.CPUDuration)
+		select {
+		case <-timer.C:
+			pprof.StopCPUProfile()
+		case <-ctx.Done():
+			timer.Stop()
+			pprof.StopCPUProfile()
+			return ctx.Err()
+		}
+	case KindHeap:
+		if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+			return fmt.Errorf("could not write heap profile: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown profile kind %s", kind)
+	}
+
+	err := p.opts.Sink.Write(ctx, Profile{
+		Kind:    kind,
+		Reason:  reason,
+		Service: p.opts.Service,
+		Version: p.opts.Version,
+		Time:    time.Now(),
+		Data:    buf.Bytes(),
+	})
+	if err != nil {
+		return fmt.Errorf("could not send %s profile: %w", kind, err)
+	}
+	return nil
+}
+
+func (p *Profiler) capture(ctx context.Context, kind Kind, reason string) {
+	if err := p.Capture(ctx, kind, reason); err != nil {
+		if ctx.Err() == nil {
+			p.logger.Warn("could not capture profile", slog.String("kind", string(kind)),
+				slog.String("reason", reason), slog.Any("error", err))
+		}
+		return
+	}
+	p.logger.Debug("captured profile", slog.String("kind", string(kind)), slog.String("reason", reason))
+}
+
+// checkTrigger captures the profiles whose threshold is reached, unless one was triggered within the cooldown.
+func (p *Profiler) checkTrigger(ctx context.Context) {
+	cpu, cpuOK, heap := p.usage.sample()
+
+	if t := p.opts.Trigger.CPU; t > 0 && cpuOK && cpu >= t && p.cooledDown(KindCPU) {
+		p.logger.Info("cpu threshold reached", slog.Float64("cpu", cpu), slog.Float64("threshold", t))
+		p.capture(ctx, KindCPU, ReasonCPUThreshold)
+	}
+	if t := p.opts.Trigger.HeapBytes; t > 0 && heap >= t && p.cooledDown(KindHeap) {
+		p.logger.Info("heap threshold reached", slog.Uint64("heap_bytes", heap), slog.Uint64("threshold", t))
+		p.capture(ctx, KindHeap, ReasonHeapThreshold)
+	}
+}
+
+func (p *Profiler) cooledDown(kind Kind) bool {
+	now := time.Now()
+	if last, ok := p.triggered[kind]; ok && now.Sub(last) < p.opts.Trigger.Cooldown {
+		return false
+	}
+	p.triggered[kind] = now
+	return true
+}
@@ -0,0 +1,110 @@
+package profiling_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thejerf/slogassert"
+
+	"github.com/davfer/goforarun/profiling"
+)
+
+type memorySink struct {
+	mu       sync.Mutex
+	profiles []profiling.Profile
+}
+
+func (s *memorySink) Write(_ context.Context, p profiling.Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles = append(s.profiles, p)
+	return nil
+}
+
+func (s *memorySink) kinds() (kinds []profiling.Kind) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.profiles {
+		kinds = append(kinds, p.Kind)
+	}
+	return
+}
+
+func runProfiler(t *testing.T, opts profiling.Options, until func() bool) {
+	p, err := profiling.New(opts, slog.New(slogassert.New(t, slog.LevelWarn, nil)))
+	require.NoError(t, err)
+
+	go func() { _ = p.Run(context.Background()) }()
+	assert.Eventually(t, until, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, p.Shutdown(context.Background()))
+}
+
+func TestProfilerPeriodic(t *testing.T) {
+	sink := &memorySink{}
+	runProfiler(t, profiling.Options{
+		Service:     "svc",
+		Version:     "1.2.3",
+		Interval:    10 * time.Millisecond,
+		CPUDuration: 20 * time.Millisecond,
+		Sink:        sink,
+	}, func() bool { return len(sink.kinds()) >= 2 })
+
+	assert.Equal(t, []profiling.Kind{profiling.KindCPU, profiling.KindHeap}, sink.kinds()[:2])
+	p := sink.profiles[0]
+	assert.Equal(t, "svc", p.Service)
+	assert.Equal(t, "1.2.3", p.Version)
+	assert.Equal(t, profiling.ReasonPeriodic, p.Reason)
+	assert.NotEmpty(t, p.Data)
+}
+
+func TestProfilerHeapTrigger(t *testing.T) {
+	sink := &memorySink{}
+	runProfiler(t, profiling.Options{
+		Trigger: profiling.Trigger{HeapBytes: 1, CheckInterval: 10 * time.Millisecond, Cooldown: time.Hour},
+		Sink:    sink,
+	}, func() bool { return len(sink.kinds()) == 1 })
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []profiling.Kind{profiling.KindHeap}, sink.kinds(), "cooldown skips the next captures")
+	assert.Equal(t, profiling.ReasonHeapThreshold, sink.profiles[0].Reason)
+}
+
+func TestProfilerShutdownDuringCPUProfile(t *testing.T) {
+	sink := &memorySink{}
+	p, err := profiling.New(profiling.Options{
+		Interval:    time.Millisecond,
+		Kinds:       []profiling.Kind{profiling.KindCPU},
+		CPUDuration: time.Hour,
+		Sink:        sink,
+	}, slog.New(slogassert.New(t, slog.LevelWarn, nil)))
+	require.NoError(t, err)
+
+	go func() { _ = p.Run(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, p.Shutdown(ctx))
+	assert.Empty(t, sink.kinds())
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	l := slog.New(slog.DiscardHandler)
+
+	_, err := profiling.New(profiling.Options{Interval: time.Minute}, l)
+	assert.EqualError(t, err, "profiling needs a sink")
+
+	_, err = profiling.New(profiling.Options{Sink: &memorySink{}}, l)
+	assert.EqualError(t, err, "profiling needs an interval or a trigger threshold")
+
+	_, err = profiling.New(profiling.Options{Trigger: profiling.Trigger{CPU: 80}, Sink: &memorySink{}}, l)
+	assert.EqualError(t, err, "invalid cpu threshold 80, must be between 0 and 1")
+
+	_, err = profiling.New(profiling.Options{Interval: time.Minute, Kinds: []profiling.Kind{"block"}, Sink: &memorySink{}}, l)
+	assert.EqualError(t, err, "unknown profile kind block")
+}
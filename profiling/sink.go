@@ -0,0 +1,170 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxFiles is the number of profiles kept by a DirSink when none is given.
+const DefaultMaxFiles = 20
+
+const profileExt = ".pb.gz"
+
+// Sink receives the captured profiles.
+type Sink interface {
+	Write(ctx context.Context, p Profile) error
+}
+
+// DirSink writes the profiles to a directory, removing the oldest ones past MaxFiles. The file names carry the
+// service, the version, the kind and the time of the profile.
+type DirSink struct {
+	dir      string
+	maxFiles int
+}
+
+// NewDirSink creates the directory, if needed, and a DirSink writing to it. A maxFiles below 1 keeps
+// DefaultMaxFiles profiles.
+func NewDirSink(dir string, maxFiles int) (*DirSink, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("could not create profiles directory: %w", err)
+	}
+	if maxFiles < 1 {
+		maxFiles = DefaultMaxFiles
+	}
+	return &DirSink{dir: dir, maxFiles: maxFiles}, nil
+}
+
+func (s *DirSink) Write(_ context.Context, p Profile) error {
+	name := filepath.Join(s.dir, fileName(p))
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, p.Data, 0o640); err != nil {
+		return fmt.Errorf("could not write profile: %w", err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("could not write profile: %w", err)
+	}
+	return s.rotate()
+}
+
+// rotate removes the oldest profiles of the directory past maxFiles.
+func (s *DirSink) rotate() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("could not list profiles: %w", err)
+	}
+
+	type file struct {
+		name    string
+		modTime time.Time
+	}
+	var files []file
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), profileExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{e.Name(), info.ModTime()})
+	}
+	if len(files) <= s.maxFiles {
+		return nil
+	}
+
+	slices.SortFunc(files, func(a, b file) int {
+		if c := a.modTime.Compare(b.modTime); c != 0 {
+			return c
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	for _, f := range files[:len(files)-s.maxFiles] {
+		err = errors.Join(err, os.Remove(filepath.Join(s.dir, f.name)))
+	}
+	return err
+}
+
+// fileName is service-version-kind-time.pb.gz, leaving out the empty tags.
+func fileName(p Profile) string {
+	parts := make([]string, 0, 4)
+	for _, tag := range []string{p.Service, p.Version, string(p.Kind)} {
+		if tag = sanitize(tag); tag != "" {
+			parts = append(parts, tag)
+		}
+	}
+	parts = append(parts, p.Time.UTC().Format("20060102T150405.000000000Z"))
+	return strings.Join(parts, "-") + profileExt
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// HTTPSink posts the profiles to an endpoint. The body is the profile and the query carries the service, version,
+// kind, reason and unix time of the capture.
+type HTTPSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTPSink creates an HTTPSink posting to endpoint with the client, http.DefaultClient when nil.
+func NewHTTPSink(endpoint string, client *http.Client) (*HTTPSink, error) {
+	if _, err := url.ParseRequestURI(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid profiles endpoint: %w", err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPSink{endpoint: endpoint, client: client}, nil
+}
+
+func (s *HTTPSink) Write(ctx context.Context, p Profile) error {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid profiles endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("service", p.Service)
+	q.Set("version", p.Version)
+	q.Set("kind", string(p.Kind))
+	q.Set("reason", p.Reason)
+	q.Set("time", strconv.FormatInt(p.Time.Unix(), 10))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(p.Data))
+	if err != nil {
+		return fmt.Errorf("could not create profile request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not post profile: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("could not post profile: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package profiling_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun/profiling"
+)
+
+func TestDirSinkRotates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+	sink, err := profiling.NewDirSink(dir, 2)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		err = sink.Write(context.Background(), profiling.Profile{
+			Kind:    profiling.KindHeap,
+			Service: "my svc",
+			Version: "1.0",
+			Time:    start.Add(time.Duration(i) * time.Second),
+			Data:    []byte{byte(i)},
+		})
+		require.NoError(t, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{
+		"my_svc-1.0-heap-20240501T100001.000000000Z.pb.gz",
+		"my_svc-1.0-heap-20240501T100002.000000000Z.pb.gz",
+	}, names)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var query url.Values
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		body, _ = io.ReadAll(r.Body)
+		if query.Get("kind") == "heap" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := profiling.NewHTTPSink(server.URL+"/ingest?token=abc", nil)
+	require.NoError(t, err)
+
+	p := profiling.Profile{
+		Kind:    profiling.KindCPU,
+		Reason:  profiling.ReasonCPUThreshold,
+		Service: "svc",
+		Version: "1.2.3",
+		Time:    time.Unix(1700000000, 0),
+		Data:    []byte("profile"),
+	}
+	require.NoError(t, sink.Write(context.Background(), p))
+	assert.Equal(t, url.Values{
+		"token":   {"abc"},
+		"service": {"svc"},
+		"version": {"1.2.3"},
+		"kind":    {"cpu"},
+		"reason":  {"cpu_threshold"},
+		"time":    {"1700000000"},
+	}, query)
+	assert.Equal(t, "profile", string(body))
+
+	p.Kind = profiling.KindHeap
+	assert.EqualError(t, sink.Write(context.Background(), p), "could not post profile: 503 Service Unavailable")
+}
@@ -0,0 +1,42 @@
+package profiling
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// usageSampler measures the CPU used by the process between two samples and the current size of the heap.
+type usageSampler struct {
+	heap    []metrics.Sample
+	lastCPU time.Duration
+	lastAt  time.Time
+}
+
+func newUsageSampler() *usageSampler {
+	return &usageSampler{heap: []metrics.Sample{{Name: heapObjectsMetric}}}
+}
+
+// sample returns the fraction of the GOMAXPROCS CPUs used since the previous sample, unknown on the first sample or
+// when the platform does not report the CPU time, and the bytes of the heap objects.
+func (u *usageSampler) sample() (cpu float64, cpuOK bool, heap uint64) {
+	metrics.Read(u.heap)
+	if u.heap[0].Value.Kind() == metrics.KindUint64 {
+		heap = u.heap[0].Value.Uint64()
+	}
+
+	now := time.Now()
+	used, ok := cpuTime()
+	if ok && !u.lastAt.IsZero() {
+		if elapsed := now.Sub(u.lastAt); elapsed > 0 {
+			cpu = float64(used-u.lastCPU) / float64(elapsed) / float64(runtime.GOMAXPROCS(0))
+			cpuOK = true
+		}
+	}
+	if ok {
+		u.lastCPU, u.lastAt = used, now
+	}
+	return
+}
@@ -0,0 +1,65 @@
+package goforarun
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+// PrometheusServerName is the name of the framework server exposing the Prometheus metrics.
+const PrometheusServerName = "prometheus"
+
+// prometheusServer serves the metrics of the Prometheus reader, managed by the framework alongside the app servers.
+type prometheusServer struct {
+	info       *InfoServer
+	path       string
+	httpServer *http.Server
+	logger     *slog.Logger
+}
+
+func newPrometheusServer(c PrometheusConfig, t *observability.Telemetry) *prometheusServer {
+	info := &InfoServer{Net: "tcp", Host: c.Host, Port: c.Port, Name: PrometheusServerName}
+	if info.Port == "" {
+		info.Port = "9464"
+	}
+	path := c.Path
+	if path == "" {
+		path = "/metrics"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+path, t.PrometheusHandler())
+
+	return &prometheusServer{
+		info: info,
+		path: path,
+		// built here rather than in Run so that Shutdown, called from another goroutine, does not race with it
+		httpServer: &http.Server{
+			Addr:    net.JoinHostPort(info.Host, info.Port),
+			Handler: mux,
+		},
+		logger: t.Logger(AppLoggerName, slog.String("server", PrometheusServerName)),
+	}
+}
+
+func (s *prometheusServer) Run(context.Context) error {
+	s.logger.With("connection", s.info, "path", s.path).Info("listening server")
+
+	err := s.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *prometheusServer) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *prometheusServer) Info() *InfoServer {
+	return s.info
+}
@@ -0,0 +1,32 @@
+package goforarun
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davfer/goforarun/observability"
+)
+
+func TestPrometheusServerShutdown(t *testing.T) {
+	telemetry, err := observability.NewTelemetry(context.Background(),
+		observability.WithPrometheus(true),
+		observability.WithSignalExporter(observability.SignalMetrics, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalTraces, observability.ExporterNone),
+		observability.WithSignalExporter(observability.SignalLogs, observability.ExporterNone),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = telemetry.Shutdown(context.Background()) })
+
+	s := newPrometheusServer(PrometheusConfig{Host: "127.0.0.1", Port: "0"}, telemetry)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Run(context.Background()) }()
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, s.Shutdown(context.Background()))
+	assert.NoError(t, <-errCh)
+}
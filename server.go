@@ -12,8 +12,8 @@ type RunnableServer interface {
 
 // InfoServer contains the information of a server to be started.
 type InfoServer struct {
-	Net  string
-	Host string
-	Port string
-	Name string
+	Net  string `yaml:"net"`
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+	Name string `yaml:"name"`
 }
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"github.com/davfer/goforarun/logger"
 	"github.com/davfer/goforarun/observability"
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	"log/slog"
 	"os"
@@ -33,11 +32,22 @@ type Service[K App[V], V Config] struct {
 	BaseService[V]
 	app     K
 	servers []RunnableServer
-	logger  *slog.Logger
+	// frameworkServers are the servers of the framework (prometheus, admin, profiler), they do not keep the app running
+	frameworkServers []RunnableServer
+	logger           *slog.Logger
+	metrics          *serviceMetrics
 }
 
 type BaseService[V any] struct {
 	Cfg V
+	// Telemetry holds the providers and the logger of the service, also in the context of Run and Shutdown
+	Telemetry *observability.Telemetry
+}
+
+// TelemetryReceiver is implemented by the apps that need the Telemetry before Init, e.g. to instrument their
+// dependencies without the globals.
+type TelemetryReceiver interface {
+	SetTelemetry(t *observability.Telemetry)
 }
 
 // NewService creates a new service with the given app and config.
@@ -56,13 +66,20 @@ func NewService[K App[V], V Config](app K, buildInfo *BuildInfo) (*Service[K, V]
 	cfg.Framework().BuildInfo = buildInfo
 
 	// observability
-	var opts []observability.Customizer
+	opts := cfg.Framework().ObservabilityConfig.customizers()
 	if cfg.Framework().ServiceName != "" {
 		opts = append(opts, observability.WithServiceName(cfg.Framework().ServiceName))
 	}
 	if buildInfo.Version != "" {
 		opts = append(opts, observability.WithServiceVersion(buildInfo.Version))
 	}
+	opts = append(opts, observability.WithBuildInfo(buildInfo.Commit, buildInfo.Date))
+	if cfg.Framework().Environment != "" {
+		opts = append(opts, observability.WithEnvironment(cfg.Framework().Environment))
+	}
+	if len(cfg.Framework().Resource) > 0 {
+		opts = append(opts, observability.WithResourceAttributes(cfg.Framework().Resource))
+	}
 	if cfg.Framework().LoggingConfig.Level != "" {
 		var l slog.Leveler
 		l, err = observability.ParseLevel(cfg.Framework().LoggingConfig.Level)
@@ -71,54 +88,136 @@ func NewService[K App[V], V Config](app K, buildInfo *BuildInfo) (*Service[K, V]
 		}
 		opts = append(opts, observability.WithLoggerLevel(l))
 	}
+	if cfg.Framework().LoggingConfig.Format != "" {
+		opts = append(opts, observability.WithLoggerFormat(cfg.Framework().LoggingConfig.Format))
+	}
 	if len(cfg.Framework().LoggingConfig.FilteredChannels) > 0 {
 		opts = append(opts, observability.WithLoggerChannels(cfg.Framework().LoggingConfig.FilteredChannels))
 	}
+	if len(cfg.Framework().LoggingConfig.Sampling.Channels) > 0 {
+		policies := make(map[string]logger.SamplingPolicy, len(cfg.Framework().LoggingConfig.Sampling.Channels))
+		for channel, p := range cfg.Framework().LoggingConfig.Sampling.Channels {
+			policies[channel] = logger.SamplingPolicy{
+				Mode:       logger.SamplingMode(p.Mode),
+				First:      p.First,
+				Thereafter: p.Thereafter,
+				Interval:   p.Interval,
+				Rate:       p.Rate,
+				Burst:      p.Burst,
+			}
+		}
+		opts = append(opts, observability.WithLoggerSampling(policies, cfg.Framework().LoggingConfig.Sampling.ReportInterval))
+	}
+	if redaction := cfg.Framework().LoggingConfig.Redaction; redaction.Enabled {
+		var policy logger.RedactionPolicy
+		policy, err = redaction.policy()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, observability.WithLoggerRedaction(policy))
+	}
+	if crashBuffer := cfg.Framework().LoggingConfig.CrashBuffer; crashBuffer.Size > 0 {
+		var l slog.Leveler
+		if crashBuffer.DumpLevel != "" {
+			l, err = observability.ParseLevel(crashBuffer.DumpLevel)
+			if err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, observability.WithLoggerCrashBuffer(crashBuffer.Size, l))
+	}
 	if val, ok := os.LookupEnv("DEBUG"); ok && val == "true" {
-		opts = append(opts, observability.WithLoggerStdout(true))
+		opts = append(opts, observability.WithLoggerStdout(true), observability.WithDebug(true))
 	}
 
-	err = observability.StartObservability(context.Background(), opts...)
+	telemetry, err := observability.NewTelemetry(context.Background(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("could not start observability: %w", err)
 	}
-	l := logger.Get(AppLoggerName)
+	if globals := cfg.Framework().ObservabilityConfig.Globals; globals == nil || *globals {
+		telemetry.SetGlobal()
+	}
+	l := telemetry.Logger(AppLoggerName)
+
+	metrics, err := newServiceMetrics(telemetry.MeterProvider, buildInfo)
+	if err != nil {
+		_ = telemetry.Shutdown(context.Background())
+		return nil, err
+	}
 
 	/////////////////////
 	// INIT USER APP
 	l.With("build", buildInfo).Debug("initializing app")
+	if receiver, ok := any(app).(TelemetryReceiver); ok {
+		receiver.SetTelemetry(telemetry)
+	}
 	servers, err := app.Init(cfg)
 	if err != nil {
 		l.Error("could not initialize app", logger.AttrErr(err))
+		_ = telemetry.Shutdown(context.Background())
 		return nil, fmt.Errorf("could not initialize app: %w", err)
 	}
+	frameworkServers, err := newFrameworkServers(cfg, telemetry)
+	if err != nil {
+		_ = telemetry.Shutdown(context.Background())
+		return nil, err
+	}
 	/////////////////////
 
 	return &Service[K, V]{
-		BaseService[V]{Cfg: cfg},
+		BaseService[V]{Cfg: cfg, Telemetry: telemetry},
 		app,
 		servers,
+		frameworkServers,
 		l,
+		metrics,
 	}, nil
 }
 
+// newFrameworkServers creates the enabled servers of the framework.
+func newFrameworkServers(cfg Config, telemetry *observability.Telemetry) ([]RunnableServer, error) {
+	var servers []RunnableServer
+	if prometheus := cfg.Framework().ObservabilityConfig.Prometheus; prometheus.Enabled {
+		servers = append(servers, newPrometheusServer(prometheus, telemetry))
+	}
+	if admin := cfg.Framework().Admin; admin.Enabled {
+		server, err := newAdminServer(admin, cfg, telemetry)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	if profiling := cfg.Framework().Profiling; profiling.Enabled {
+		server, err := newProfilerServer(profiling, cfg.Framework(), telemetry)
+		if err != nil {
+			return nil, fmt.Errorf("could not start profiling: %w", err)
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
 // Run starts the service and blocks until it receives a SIGINT or the app crashes.
 // If the app Run method returns an error, the service will log it and exit.
 func (s *Service[K, V]) Run(ctx context.Context) {
-	tracedCtx, span := otel.Tracer(AppLoggerName).Start(ctx, "run")
+	ctx = observability.ContextWithTelemetry(ctx, s.Telemetry)
+	tracedCtx, span := s.Telemetry.TracerProvider.Tracer(AppLoggerName).Start(ctx, "run")
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt)
 
 	errCh := make(chan error)
-	for i := range s.servers {
+	servers := append(append([]RunnableServer{}, s.servers...), s.frameworkServers...)
+	for i := range servers {
 		go func(server RunnableServer) {
 			s.logger.With("server", server.Info().Name).Debug("starting unmanaged server")
+			stopped := s.metrics.serverStarted(tracedCtx, server.Info().Name)
 			err := server.Run(tracedCtx)
+			stopped()
 			if err != nil {
 				errCh <- err
 			}
-		}(s.servers[i])
+		}(servers[i])
 	}
 	// TODO: add condition to wait for all servers to be listening
 
@@ -135,16 +234,11 @@ func (s *Service[K, V]) Run(ctx context.Context) {
 		select {
 		case <-sigCh:
 			s.logger.Info("starting soft shutdown")
+			shutdownStart := time.Now()
 
 			ctxShutdown, cancel := context.WithTimeout(tracedCtx, 10*time.Second)
 
-			for _, server := range s.servers {
-				s.logger.With("server", server.Info().Name).Debug("shutting down unmanaged server")
-				err := server.Shutdown(ctxShutdown)
-				if err != nil {
-					s.logger.With("server", server.Info().Name).Error("error while shutting down unmanaged server", logger.AttrErr(err))
-				}
-			}
+			s.shutdownServers(ctxShutdown, servers)
 
 			s.logger.Debug("shutting down app")
 			err := s.app.Shutdown(ctxShutdown)
@@ -154,9 +248,10 @@ func (s *Service[K, V]) Run(ctx context.Context) {
 				s.logger.Error("error while shutting down business app", logger.AttrErr(err))
 			}
 			span.End()
+			s.metrics.shutdownDuration.Record(ctxShutdown, time.Since(shutdownStart).Seconds())
 
 			s.logger.Debug("shutting down observability")
-			err = observability.StopObservability(ctxShutdown)
+			err = s.Telemetry.Shutdown(ctxShutdown)
 			if err != nil {
 				s.logger.Error("error while closing observability", logger.AttrErr(err))
 			}
@@ -172,17 +267,27 @@ func (s *Service[K, V]) Run(ctx context.Context) {
 			}
 			span.End()
 
+			ctxShutdown, cancel := context.WithTimeout(ctx, 10*time.Second)
+			s.shutdownServers(ctxShutdown, servers)
+			cancel()
+
 			if errors.Is(err, ErrGracefulShutdown) || err == nil {
 				s.logger.Info("graceful shutdown")
 
+				err = s.Telemetry.Shutdown(context.Background())
+				if err != nil {
+					s.logger.Error("error while closing observability", logger.AttrErr(err))
+				}
+
 				os.Exit(0)
 
 				return
 			}
 
 			s.logger.Error("service crashed", logger.AttrErr(err))
+			s.Telemetry.DumpCrashBuffer(context.Background())
 
-			err = observability.StopObservability(context.Background())
+			err = s.Telemetry.Shutdown(context.Background())
 			if err != nil {
 				s.logger.Error("error while closing observability", logger.AttrErr(err))
 			}
@@ -191,3 +296,13 @@ func (s *Service[K, V]) Run(ctx context.Context) {
 		}
 	}
 }
+
+func (s *Service[K, V]) shutdownServers(ctx context.Context, servers []RunnableServer) {
+	for _, server := range servers {
+		s.logger.With("server", server.Info().Name).Debug("shutting down unmanaged server")
+		err := server.Shutdown(ctx)
+		if err != nil {
+			s.logger.With("server", server.Info().Name).Error("error while shutting down unmanaged server", logger.AttrErr(err))
+		}
+	}
+}